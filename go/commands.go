@@ -0,0 +1,52 @@
+package main
+
+import (
+	"blog-api/internal/services"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+// runCommand runs a one-off maintenance job instead of the server, e.g.
+// `blog-api backfill-placeholders`.
+func runCommand(name string, args []string) error {
+	switch name {
+	case "backfill-placeholders":
+		return backfillPlaceholders(args)
+	default:
+		return fmt.Errorf("unknown command %q (available: backfill-placeholders)", name)
+	}
+}
+
+func backfillPlaceholders(args []string) error {
+	flags := flag.NewFlagSet("backfill-placeholders", flag.ContinueOnError)
+	force := flags.Bool("force", false, "recompute placeholders that already exist")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	storage, err := services.NewStorage()
+	if err != nil {
+		return err
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	report, err := services.NewAssetService(storage).BackfillPlaceholders(ctx, services.NewImageService(), *force)
+	if report != nil {
+		out, _ := json.MarshalIndent(report, "", "  ")
+		fmt.Println(string(out))
+	}
+	if err != nil {
+		return err
+	}
+	if len(report.Failed) > 0 {
+		return fmt.Errorf("%d images could not be processed", len(report.Failed))
+	}
+	return nil
+}
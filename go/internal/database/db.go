@@ -89,10 +89,30 @@ func forceIPv4(dsn string) string {
 }
 
 func Migrate() error {
-	err := DB.AutoMigrate(&models.Blog{}, &models.Auth{})
+	err := DB.AutoMigrate(
+		&models.Blog{},
+		&models.Auth{},
+		&models.PageView{},
+		&models.BlogDailyStat{},
+		&models.BlogReferrerStat{},
+		&models.Reaction{},
+		&models.ReactionEvent{},
+		&models.Comment{},
+		&models.SpamSettings{},
+		&models.SlugHistory{},
+		&models.Media{},
+		&models.Asset{},
+		&models.SpentChallenge{},
+	)
 	if err != nil {
 		return fmt.Errorf("failed to migrate database: %w", err)
 	}
+
+	// Posts published through an update used to be left without a
+	// publication date.
+	if err := DB.Exec("UPDATE blogs SET published_at = created_at WHERE status = ? AND published_at IS NULL", "published").Error; err != nil {
+		return fmt.Errorf("failed to backfill published_at: %w", err)
+	}
 	return nil
 }
 
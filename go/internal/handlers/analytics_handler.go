@@ -0,0 +1,241 @@
+package handlers
+
+import (
+	"blog-api/internal/models"
+	"blog-api/internal/repository"
+	"blog-api/internal/utils"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+const dateLayout = "2006-01-02"
+
+type AnalyticsHandler struct {
+	repo          *repository.AnalyticsRepository
+	blogRepo      *repository.BlogRepository
+	siteHost      string
+	retentionDays int
+}
+
+func NewAnalyticsHandler() *AnalyticsHandler {
+	siteHost := ""
+	if u, err := url.Parse(os.Getenv("SITE_URL")); err == nil {
+		siteHost = u.Hostname()
+	}
+
+	retentionDays := 2
+	if parsed, err := strconv.Atoi(os.Getenv("ANALYTICS_VISITOR_RETENTION_DAYS")); err == nil && parsed > 0 {
+		retentionDays = parsed
+	}
+
+	h := &AnalyticsHandler{
+		repo:          repository.NewAnalyticsRepository(),
+		blogRepo:      repository.NewBlogRepository(),
+		siteHost:      siteHost,
+		retentionDays: retentionDays,
+	}
+	go h.purgeVisitors()
+	return h
+}
+
+// purgeVisitors drops per-visitor rows once they are older than the
+// retention window. Only the current day's rows are needed to count unique
+// visitors; the daily totals are kept.
+func (h *AnalyticsHandler) purgeVisitors() {
+	for {
+		before := time.Now().UTC().Truncate(24*time.Hour).AddDate(0, 0, -h.retentionDays)
+		if _, err := h.repo.PurgeVisitors(before); err != nil {
+			log.Printf("Warning: %v", err)
+		}
+		time.Sleep(time.Hour)
+	}
+}
+
+func (h *AnalyticsHandler) RecordView(c *gin.Context) {
+	blog, err := h.blogRepo.GetByAnySlug(c.Param("slug"))
+	if err != nil || blog.Status != "published" {
+		c.JSON(http.StatusNotFound, gin.H{"error": "blog not found"})
+		return
+	}
+
+	var req models.RecordViewRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	userAgent := c.Request.UserAgent()
+	deviceClass := utils.DeviceClass(userAgent)
+	if deviceClass == "bot" {
+		c.Status(http.StatusNoContent)
+		return
+	}
+
+	day := time.Now().UTC().Truncate(24 * time.Hour)
+	view := &models.PageView{
+		BlogID:       blog.ID,
+		Day:          day,
+		VisitorHash:  utils.VisitorHash(c.ClientIP(), userAgent, day),
+		ReferrerHost: utils.ReferrerHost(req.Referrer, h.siteHost),
+		DeviceClass:  deviceClass,
+		Views:        1,
+	}
+
+	if err := h.repo.RecordView(view); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+func (h *AnalyticsHandler) GetTopPosts(c *gin.Context) {
+	from, to, ok := parseDateRange(c)
+	if !ok {
+		return
+	}
+
+	posts, err := h.repo.TopPosts(from, to, parseLimit(c, 10, 100))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"posts": posts,
+		"from":  from.Format(dateLayout),
+		"to":    to.Format(dateLayout),
+	})
+}
+
+func (h *AnalyticsHandler) GetBlogViews(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id format"})
+		return
+	}
+
+	from, to, ok := parseDateRange(c)
+	if !ok {
+		return
+	}
+
+	stats, err := h.repo.DailyStats(id, from, to)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	byDay := make(map[string]*models.BlogDailyStat, len(stats))
+	for _, s := range stats {
+		byDay[s.Day.UTC().Format(dateLayout)] = s
+	}
+
+	series := make([]models.DailyViews, 0, int(to.Sub(from).Hours()/24)+1)
+	totalViews, totalUnique := 0, 0
+	for day := from; !day.After(to); day = day.AddDate(0, 0, 1) {
+		key := day.Format(dateLayout)
+		point := models.DailyViews{Day: key}
+		if s, ok := byDay[key]; ok {
+			point.Views = s.Views
+			point.UniqueVisitors = s.UniqueVisitors
+		}
+		totalViews += point.Views
+		totalUnique += point.UniqueVisitors
+		series = append(series, point)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"blog_id":         id,
+		"from":            from.Format(dateLayout),
+		"to":              to.Format(dateLayout),
+		"views":           totalViews,
+		"unique_visitors": totalUnique,
+		"series":          series,
+	})
+}
+
+func (h *AnalyticsHandler) GetTopReferrers(c *gin.Context) {
+	from, to, ok := parseDateRange(c)
+	if !ok {
+		return
+	}
+
+	var blogID *uuid.UUID
+	if idStr := c.Query("blog_id"); idStr != "" {
+		id, err := uuid.Parse(idStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid blog_id format"})
+			return
+		}
+		blogID = &id
+	}
+
+	referrers, err := h.repo.TopReferrers(blogID, from, to, parseLimit(c, 10, 100))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"referrers": referrers,
+		"from":      from.Format(dateLayout),
+		"to":        to.Format(dateLayout),
+	})
+}
+
+// parseDateRange reads ?from=YYYY-MM-DD&to=YYYY-MM-DD, defaulting to the last
+// 30 days. It writes the 400 response itself when the range is invalid.
+func parseDateRange(c *gin.Context) (time.Time, time.Time, bool) {
+	to := time.Now().UTC().Truncate(24 * time.Hour)
+	from := to.AddDate(0, 0, -29)
+
+	if toStr := c.Query("to"); toStr != "" {
+		parsed, err := time.Parse(dateLayout, toStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid to date, expected YYYY-MM-DD"})
+			return time.Time{}, time.Time{}, false
+		}
+		to = parsed
+		from = to.AddDate(0, 0, -29)
+	}
+	if fromStr := c.Query("from"); fromStr != "" {
+		parsed, err := time.Parse(dateLayout, fromStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid from date, expected YYYY-MM-DD"})
+			return time.Time{}, time.Time{}, false
+		}
+		from = parsed
+	}
+
+	if from.After(to) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "from must not be after to"})
+		return time.Time{}, time.Time{}, false
+	}
+	if to.Sub(from) > 366*24*time.Hour {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "date range must not exceed one year"})
+		return time.Time{}, time.Time{}, false
+	}
+
+	return from, to, true
+}
+
+func parseLimit(c *gin.Context, def, max int) int {
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", strconv.Itoa(def)))
+	if err != nil || limit < 1 {
+		return def
+	}
+	if limit > max {
+		return max
+	}
+	return limit
+}
@@ -0,0 +1,131 @@
+package handlers
+
+import (
+	"blog-api/internal/models"
+	"blog-api/internal/services"
+	"context"
+	"fmt"
+	"mime/multipart"
+	"net/http"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// attachmentRef matches attachment:<name> as a Markdown link or image
+// destination, inline or in a reference definition.
+var attachmentRef = regexp.MustCompile(`(?m)(\]\(\s*<?|^[ ]{0,3}\[[^\]]+\]:[ \t]*<?)attachment:([^\s()<>"']+)`)
+
+// attachmentRefs returns the submatch indexes of attachment references in
+// content, leaving out ones inside code blocks and code spans: those only
+// show the syntax.
+func attachmentRefs(markdown *services.MarkdownService, content string) [][]int {
+	code := markdown.CodeRanges(content)
+	var refs [][]int
+	for _, match := range attachmentRef.FindAllStringSubmatchIndex(content, -1) {
+		if !code.Contains(match[3]) {
+			refs = append(refs, match)
+		}
+	}
+	return refs
+}
+
+// attachmentFiles returns the files sent in the "attachments" form field.
+func attachmentFiles(c *gin.Context) []*multipart.FileHeader {
+	if !strings.HasPrefix(c.ContentType(), "multipart/form-data") {
+		return nil
+	}
+	form, err := c.MultipartForm()
+	if err != nil {
+		return nil
+	}
+	return form.File["attachments"]
+}
+
+// prepareAttachments matches the attachment:<name> references in content to
+// the uploaded files by file name and validates each image. Every reference
+// needs a file and every file a reference. It writes the error response
+// itself and returns false when anything does not line up.
+func prepareAttachments(c *gin.Context, images *services.ImageService, markdown *services.MarkdownService, content string, files []*multipart.FileHeader) (map[string]*services.PreparedImage, bool) {
+	byName := make(map[string]*multipart.FileHeader, len(files))
+	for _, file := range files {
+		name := filepath.Base(file.Filename)
+		if _, dup := byName[name]; dup {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("attachment %q was uploaded more than once", name)})
+			return nil, false
+		}
+		byName[name] = file
+	}
+
+	prepared := make(map[string]*services.PreparedImage)
+	for _, match := range attachmentRefs(markdown, content) {
+		name := content[match[4]:match[5]]
+		if _, done := prepared[name]; done {
+			continue
+		}
+		file, ok := byName[name]
+		if !ok {
+			closeImages(prepared)
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("content references attachment:%s, but no such file was uploaded", name)})
+			return nil, false
+		}
+		image, ok := prepareImageUpload(c, images, file)
+		if !ok {
+			closeImages(prepared)
+			return nil, false
+		}
+		prepared[name] = image
+	}
+
+	for name := range byName {
+		if _, ok := prepared[name]; !ok {
+			closeImages(prepared)
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("attachment %q is not referenced in content", name)})
+			return nil, false
+		}
+	}
+	return prepared, true
+}
+
+func closeImages(images map[string]*services.PreparedImage) {
+	for _, image := range images {
+		image.Close()
+	}
+}
+
+// uploadAttachments stores the prepared attachments for a post and rewrites
+// their references in content to the stored URLs. It returns the keys it
+// stored so the caller can release them if saving the post fails.
+func uploadAttachments(ctx context.Context, assets *services.AssetService, markdown *services.MarkdownService, content string, prepared map[string]*services.PreparedImage, blogID uuid.UUID) (string, []string, error) {
+	urls := make(map[string]string, len(prepared))
+	var keys []string
+	for name, image := range prepared {
+		key := services.InlineImagePrefix + uuid.New().String() + image.Ext
+		stored, err := assets.Upload(ctx, key, image.Open(), image.Size, image.MimeType, models.AssetOwnerBlog, blogID)
+		if err != nil {
+			releaseKeys(ctx, assets, keys)
+			return "", nil, fmt.Errorf("failed to upload attachment %s: %w", name, err)
+		}
+		keys = append(keys, key)
+		urls[name] = stored.URL
+	}
+
+	var rewritten strings.Builder
+	last := 0
+	for _, match := range attachmentRefs(markdown, content) {
+		rewritten.WriteString(content[last:match[3]])
+		rewritten.WriteString(urls[content[match[4]:match[5]]])
+		last = match[1]
+	}
+	rewritten.WriteString(content[last:])
+	return rewritten.String(), keys, nil
+}
+
+func releaseKeys(ctx context.Context, assets *services.AssetService, keys []string) {
+	for _, key := range keys {
+		assets.Release(ctx, key)
+	}
+}
@@ -5,9 +5,12 @@ import (
 	"blog-api/internal/repository"
 	"blog-api/internal/services"
 	"blog-api/internal/utils"
+	"context"
+	"errors"
 	"fmt"
+	"mime/multipart"
 	"net/http"
-	"path/filepath"
+	"net/url"
 	"strconv"
 	"strings"
 
@@ -16,30 +19,134 @@ import (
 )
 
 type BlogHandler struct {
-	repo              *repository.BlogRepository
-	cloudinaryService *services.CloudinaryService
+	repo            *repository.BlogRepository
+	reactionRepo    *repository.ReactionRepository
+	mediaRepo       *repository.MediaRepository
+	assets          *services.AssetService
+	images          *services.ImageService
+	markdownService *services.MarkdownService
+	sanitizer       *services.SanitizerService
 }
 
-func NewBlogHandler(cloudinaryService *services.CloudinaryService) *BlogHandler {
+func NewBlogHandler(assets *services.AssetService, images *services.ImageService, markdownService *services.MarkdownService, sanitizer *services.SanitizerService) *BlogHandler {
 	return &BlogHandler{
-		repo:              repository.NewBlogRepository(),
-		cloudinaryService: cloudinaryService,
+		repo:            repository.NewBlogRepository(),
+		reactionRepo:    repository.NewReactionRepository(),
+		mediaRepo:       repository.NewMediaRepository(),
+		assets:          assets,
+		images:          images,
+		markdownService: markdownService,
+		sanitizer:       sanitizer,
 	}
 }
 
+// sanitizeFields cleans the user-supplied text fields in place. It returns
+// false after writing a 422 when the sanitizer runs in reject mode and found
+// something to remove.
+func (h *BlogHandler) sanitizeFields(c *gin.Context, title, content, excerpt *string) ([]models.SanitizeFinding, bool) {
+	var findings []models.SanitizeFinding
+	var found []models.SanitizeFinding
+
+	if title != nil {
+		*title, found = h.sanitizer.SanitizeText("title", *title)
+		findings = append(findings, found...)
+	}
+	if excerpt != nil {
+		*excerpt, found = h.sanitizer.SanitizeText("excerpt", *excerpt)
+		findings = append(findings, found...)
+	}
+	if content != nil {
+		*content, found = h.sanitizer.SanitizeMarkdown("content", *content)
+		findings = append(findings, found...)
+	}
+
+	if len(findings) > 0 && h.sanitizer.Mode() == services.SanitizeModeReject {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{
+			"error":        "content contains disallowed HTML",
+			"sanitization": findings,
+		})
+		return nil, false
+	}
+	return findings, true
+}
+
+func (h *BlogHandler) renderContent(blog *models.Blog) error {
+	rendered, err := h.markdownService.Render(blog.Content)
+	if err != nil {
+		return err
+	}
+	blog.ContentHTML = rendered.HTML
+	blog.TOC = rendered.TOC
+	return nil
+}
+
+func optionalString(value string) *string {
+	if value == "" {
+		return nil
+	}
+	return &value
+}
+
+func (h *BlogHandler) attachReactions(blogs ...*models.Blog) error {
+	ids := make([]uuid.UUID, len(blogs))
+	for i, blog := range blogs {
+		ids[i] = blog.ID
+	}
+
+	counts, err := h.reactionRepo.CountsForBlogs(ids)
+	if err != nil {
+		return err
+	}
+	for _, blog := range blogs {
+		blog.Reactions = make(map[string]int64, len(models.ReactionTypes))
+		for reactionType := range models.ReactionTypes {
+			blog.Reactions[reactionType] = counts[blog.ID][reactionType]
+		}
+	}
+	return nil
+}
+
 func (h *BlogHandler) CreateBlog(c *gin.Context) {
+	if !parseUploadForm(c) {
+		return
+	}
 	title := c.PostForm("title")
 	content := c.PostForm("content")
 	excerpt := c.PostForm("excerpt")
 	category := c.PostForm("category")
 	tagsStr := c.PostForm("tags")
 	status := c.PostForm("status")
+	metaDescription := strings.TrimSpace(c.PostForm("meta_description"))
+	canonicalURL := strings.TrimSpace(c.PostForm("canonical_url"))
+	ogImage := strings.TrimSpace(c.PostForm("og_image"))
+	twitterCard := strings.TrimSpace(c.PostForm("twitter_card"))
+	noIndex, _ := strconv.ParseBool(c.PostForm("noindex"))
 
 	if title == "" || content == "" {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "title and content are required"})
 		return
 	}
 
+	if err := validateSEOFields(&metaDescription, &canonicalURL, &ogImage, &twitterCard); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	findings, ok := h.sanitizeFields(c, &title, &content, &excerpt)
+	if !ok {
+		return
+	}
+	if strings.TrimSpace(title) == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "title is empty after sanitization"})
+		return
+	}
+
+	attachments, ok := prepareAttachments(c, h.images, h.markdownService, content, attachmentFiles(c))
+	if !ok {
+		return
+	}
+	defer closeImages(attachments)
+
 	slug := utils.GenerateSlug(title)
 
 	if status == "" {
@@ -60,58 +167,45 @@ func (h *BlogHandler) CreateBlog(c *gin.Context) {
 		tags = models.StringArray(tagList)
 	}
 
+	id := uuid.New()
 	var featuredImageURL *string
+	var uploaded *uploadedImage
 	file, err := c.FormFile("image")
 	if err != http.ErrMissingFile && err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "failed to get image file: " + err.Error()})
 		return
 	}
-	
-	if err == nil && file != nil {
-		src, err := file.Open()
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to open image file"})
-			return
-		}
-		defer src.Close()
-
-		ext := strings.ToLower(filepath.Ext(file.Filename))
-		allowedExts := []string{".jpg", ".jpeg", ".png", ".gif", ".webp"}
-		allowed := false
-		for _, allowedExt := range allowedExts {
-			if ext == allowedExt {
-				allowed = true
-				break
-			}
-		}
 
-		if !allowed {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid image file type. Allowed types: jpg, jpeg, png, gif, webp"})
+	if err == nil && file != nil {
+		image, ok := prepareImageUpload(c, h.images, file)
+		if !ok {
 			return
 		}
+		defer image.Close()
 
-		if file.Size > 10*1024*1024 {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "image file size exceeds 10MB limit"})
+		uploaded, err = uploadImage(c.Request.Context(), h.assets, h.images, image, services.BlogImagePrefix+uuid.New().String(), models.AssetOwnerBlog, id)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("failed to upload image: %v", err)})
 			return
 		}
 
-		if h.cloudinaryService == nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "image upload service is not available"})
-			return
-		}
+		featuredImageURL = &uploaded.URL
+	}
 
-		publicID := uuid.New().String()
-		uploadedURL, err := h.cloudinaryService.UploadImage(c.Request.Context(), src, publicID)
+	var attachmentKeys []string
+	if len(attachments) > 0 {
+		content, attachmentKeys, err = uploadAttachments(c.Request.Context(), h.assets, h.markdownService, content, attachments, id)
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("failed to upload image: %v", err)})
+			if uploaded != nil {
+				releaseUpload(c.Request.Context(), h.assets, uploaded)
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 			return
 		}
-
-		featuredImageURL = &uploadedURL
 	}
 
 	blog := &models.Blog{
-		ID:            uuid.New(),
+		ID:            id,
 		Title:         title,
 		Slug:          slug,
 		Content:       content,
@@ -120,9 +214,33 @@ func (h *BlogHandler) CreateBlog(c *gin.Context) {
 		Tags:          tags,
 		Status:        status,
 		FeaturedImage: featuredImageURL,
+		ContentStats:  h.markdownService.Stats(content),
+		SEOFields: models.SEOFields{
+			MetaDescription: optionalString(metaDescription),
+			CanonicalURL:    optionalString(canonicalURL),
+			OGImage:         optionalString(ogImage),
+			NoIndex:         noIndex,
+			TwitterCard:     twitterCard,
+		},
+		Sanitization: findings,
+	}
+
+	if uploaded != nil {
+		blog.FeaturedImageVariants = uploaded.Variants
+		blog.FeaturedImageMeta = uploaded.Placeholder.FeaturedImageMeta()
+		blog.FeaturedImageResponsive = uploaded.Variants.Responsive()
 	}
 
 	if err := h.repo.Create(blog); err != nil {
+		if uploaded != nil {
+			releaseUpload(c.Request.Context(), h.assets, uploaded)
+		}
+		releaseKeys(c.Request.Context(), h.assets, attachmentKeys)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.renderContent(blog); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -144,15 +262,48 @@ func (h *BlogHandler) GetBlog(c *gin.Context) {
 		return
 	}
 
+	if err := h.attachReactions(blog); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.renderContent(blog); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
 	c.JSON(http.StatusOK, blog)
 }
 
 func (h *BlogHandler) GetBlogBySlug(c *gin.Context) {
 	slug := c.Param("slug")
-	
+
 	blog, err := h.repo.GetBySlug(slug)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "blog not found"})
+		// Links shared before a rename point at an old slug; send clients on
+		// to the post's current address instead of a 404.
+		current, resolveErr := h.repo.ResolveOldSlug(slug)
+		if resolveErr != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "blog not found"})
+			return
+		}
+		location := "/api/v1/blogs/slug/" + url.PathEscape(current)
+		c.Header("Location", location)
+		c.JSON(http.StatusMovedPermanently, gin.H{
+			"error":    "blog has moved",
+			"slug":     current,
+			"location": location,
+		})
+		return
+	}
+
+	if err := h.attachReactions(blog); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.renderContent(blog); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
@@ -179,6 +330,11 @@ func (h *BlogHandler) GetAllBlogs(c *gin.Context) {
 		return
 	}
 
+	if err := h.attachReactions(blogs...); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
 	c.JSON(http.StatusOK, gin.H{
 		"blogs":  blogs,
 		"limit":  limit,
@@ -194,22 +350,77 @@ func (h *BlogHandler) UpdateBlog(c *gin.Context) {
 		return
 	}
 
+	if !parseUploadForm(c) {
+		return
+	}
+
 	var req models.UpdateBlogRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
+	var imageFile *multipart.FileHeader
+	if strings.HasPrefix(c.ContentType(), "multipart/form-data") {
+		req, imageFile, err = bindUpdateForm(c)
+	} else {
+		err = c.ShouldBindJSON(&req)
+	}
+	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
+	if imageFile != nil && req.FeaturedImage != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "provide either an image file or featured_image, not both"})
+		return
+	}
+
+	files := attachmentFiles(c)
+	if len(files) > 0 && req.Content == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "attachments can only be sent together with content"})
+		return
+	}
+
+	if err := validateSEOFields(req.MetaDescription, req.CanonicalURL, req.OGImage, req.TwitterCard); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	findings, ok := h.sanitizeFields(c, req.Title, req.Content, req.Excerpt)
+	if !ok {
+		return
+	}
+	if req.Title != nil && strings.TrimSpace(*req.Title) == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "title is empty after sanitization"})
+		return
+	}
+
+	var attachments map[string]*services.PreparedImage
+	if req.Content != nil {
+		if attachments, ok = prepareAttachments(c, h.images, h.markdownService, *req.Content, files); !ok {
+			return
+		}
+		defer closeImages(attachments)
+	}
+
 	updates := make(map[string]interface{})
 
 	if req.Title != nil {
 		updates["title"] = *req.Title
 	}
 	if req.Slug != nil {
-		updates["slug"] = *req.Slug
+		if strings.TrimSpace(*req.Slug) == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "slug must not be empty"})
+			return
+		}
+		slug := utils.GenerateSlug(*req.Slug)
+		if utils.IsReservedSlug(slug) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("slug %q is reserved", slug)})
+			return
+		}
+		updates["slug"] = slug
 	}
 	if req.Content != nil {
 		updates["content"] = *req.Content
+		for column, value := range h.markdownService.Stats(*req.Content).Updates() {
+			updates[column] = value
+		}
 	}
 	if req.Excerpt != nil {
 		updates["excerpt"] = *req.Excerpt
@@ -225,27 +436,207 @@ func (h *BlogHandler) UpdateBlog(c *gin.Context) {
 	}
 	if req.FeaturedImage != nil {
 		updates["featured_image"] = *req.FeaturedImage
+		// Pointing a post at a library image carries its variants and
+		// placeholder along; anything else clears them.
+		var variants models.ImageVariants
+		var placeholder *models.ImagePlaceholder
+		if media, err := h.mediaRepo.GetByURL(*req.FeaturedImage); err == nil {
+			variants, placeholder = media.Variants, media.Placeholder()
+		}
+		updates["featured_image_variants"] = variants
+		for column, value := range placeholder.FeaturedImageMeta().Updates() {
+			updates[column] = value
+		}
+	}
+	if req.MetaDescription != nil {
+		updates["meta_description"] = optionalString(*req.MetaDescription)
+	}
+	if req.CanonicalURL != nil {
+		updates["canonical_url"] = optionalString(*req.CanonicalURL)
+	}
+	if req.OGImage != nil {
+		updates["og_image"] = optionalString(*req.OGImage)
+	}
+	if req.NoIndex != nil {
+		updates["no_index"] = *req.NoIndex
+	}
+	if req.TwitterCard != nil {
+		updates["twitter_card"] = *req.TwitterCard
 	}
 
-	if len(updates) == 0 {
+	if len(updates) == 0 && imageFile == nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "no fields to update"})
 		return
 	}
 
+	// A new image is uploaded before the row is touched; the old one is only
+	// removed from storage after the update has committed.
+	var previous *models.Blog
+	if imageFile != nil || req.FeaturedImage != nil {
+		current, err := h.repo.GetByID(id)
+		if err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "blog not found"})
+			return
+		}
+		previous = current
+	}
+
+	var uploaded *uploadedImage
+	if imageFile != nil {
+		image, ok := prepareImageUpload(c, h.images, imageFile)
+		if !ok {
+			return
+		}
+		defer image.Close()
+
+		uploaded, err = uploadImage(c.Request.Context(), h.assets, h.images, image, services.BlogImagePrefix+uuid.New().String(), models.AssetOwnerBlog, id)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("failed to upload image: %v", err)})
+			return
+		}
+		updates["featured_image"] = uploaded.URL
+		updates["featured_image_variants"] = uploaded.Variants
+		for column, value := range uploaded.Placeholder.FeaturedImageMeta().Updates() {
+			updates[column] = value
+		}
+	}
+
+	var attachmentKeys []string
+	if len(attachments) > 0 {
+		content, keys, err := uploadAttachments(c.Request.Context(), h.assets, h.markdownService, *req.Content, attachments, id)
+		if err != nil {
+			if uploaded != nil {
+				releaseUpload(c.Request.Context(), h.assets, uploaded)
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		updates["content"] = content
+		attachmentKeys = keys
+	}
+
 	if err := h.repo.Update(id, updates); err != nil {
+		if uploaded != nil {
+			releaseUpload(c.Request.Context(), h.assets, uploaded)
+		}
+		releaseKeys(c.Request.Context(), h.assets, attachmentKeys)
+		if errors.Is(err, repository.ErrSlugTaken) {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
+	if previous != nil && previous.FeaturedImage != nil {
+		h.removeUnusedImage(c.Request.Context(), id, *previous.FeaturedImage, previous.FeaturedImageVariants)
+	}
+	if content, ok := updates["content"].(string); ok {
+		h.removeUnusedAttachments(c.Request.Context(), id, content)
+	}
+
 	blog, err := h.repo.GetByID(id)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch updated blog"})
 		return
 	}
+	blog.Sanitization = findings
+
+	if err := h.renderContent(blog); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
 
 	c.JSON(http.StatusOK, blog)
 }
 
+// removeUnusedImage deletes a featured image that was uploaded for this
+// post once no post shows it any more. Media library files and external
+// URLs are left alone. Failures are only logged; the post is already saved.
+func (h *BlogHandler) removeUnusedImage(ctx context.Context, blogID uuid.UUID, url string, variants models.ImageVariants) {
+	asset, ok := h.assets.Owner(url)
+	if !ok || asset.OwnerType != models.AssetOwnerBlog || asset.OwnerID == nil || *asset.OwnerID != blogID {
+		return
+	}
+
+	if _, used, err := h.repo.FindImageUser(url); err != nil || used {
+		return
+	}
+
+	h.assets.Release(ctx, asset.Key)
+	for _, variant := range variants {
+		if asset, ok := h.assets.Owner(variant.URL); ok && asset.OwnerType == models.AssetOwnerBlog && asset.OwnerID != nil && *asset.OwnerID == blogID {
+			h.assets.Release(ctx, asset.Key)
+		}
+	}
+}
+
+// removeUnusedAttachments deletes inline images of the post that its
+// content no longer links to.
+func (h *BlogHandler) removeUnusedAttachments(ctx context.Context, blogID uuid.UUID, content string) {
+	assets, err := h.assets.Owned(models.AssetOwnerBlog, blogID)
+	if err != nil {
+		return
+	}
+	for _, asset := range assets {
+		if strings.HasPrefix(asset.Key, services.InlineImagePrefix) && !strings.Contains(content, asset.URL) {
+			h.assets.Release(ctx, asset.Key)
+		}
+	}
+}
+
+// bindUpdateForm reads a multipart update. Only fields present in the form
+// are set, mirroring how omitted JSON fields are left untouched.
+func bindUpdateForm(c *gin.Context) (models.UpdateBlogRequest, *multipart.FileHeader, error) {
+	var req models.UpdateBlogRequest
+
+	fields := map[string]**string{
+		"title":            &req.Title,
+		"slug":             &req.Slug,
+		"content":          &req.Content,
+		"excerpt":          &req.Excerpt,
+		"category":         &req.Category,
+		"status":           &req.Status,
+		"featured_image":   &req.FeaturedImage,
+		"meta_description": &req.MetaDescription,
+		"canonical_url":    &req.CanonicalURL,
+		"og_image":         &req.OGImage,
+		"twitter_card":     &req.TwitterCard,
+	}
+	for name, field := range fields {
+		if value, ok := c.GetPostForm(name); ok {
+			*field = &value
+		}
+	}
+
+	if tagsStr, ok := c.GetPostForm("tags"); ok {
+		tags := []string{}
+		for _, tag := range strings.Split(tagsStr, ",") {
+			if tag = strings.TrimSpace(tag); tag != "" {
+				tags = append(tags, tag)
+			}
+		}
+		req.Tags = &tags
+	}
+
+	if noIndexStr, ok := c.GetPostForm("noindex"); ok {
+		noIndex, err := strconv.ParseBool(noIndexStr)
+		if err != nil {
+			return req, nil, fmt.Errorf("invalid noindex value")
+		}
+		req.NoIndex = &noIndex
+	}
+
+	file, err := c.FormFile("image")
+	if err == http.ErrMissingFile {
+		return req, nil, nil
+	}
+	if err != nil {
+		return req, nil, fmt.Errorf("failed to get image file: %w", err)
+	}
+	return req, file, nil
+}
+
 func (h *BlogHandler) DeleteBlog(c *gin.Context) {
 	idStr := c.Param("id")
 	id, err := uuid.Parse(idStr)
@@ -259,5 +650,9 @@ func (h *BlogHandler) DeleteBlog(c *gin.Context) {
 		return
 	}
 
+	// Anything that cannot be removed now, or that another post still shows,
+	// is left for the reconcile job.
+	h.assets.ReleaseUnused(c.Request.Context(), models.AssetOwnerBlog, id)
+
 	c.JSON(http.StatusOK, gin.H{"message": "blog deleted successfully"})
 }
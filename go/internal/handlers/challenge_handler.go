@@ -0,0 +1,21 @@
+package handlers
+
+import (
+	"blog-api/internal/services"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type ChallengeHandler struct {
+	pow *services.ProofOfWorkService
+}
+
+func NewChallengeHandler(pow *services.ProofOfWorkService) *ChallengeHandler {
+	return &ChallengeHandler{pow: pow}
+}
+
+func (h *ChallengeHandler) IssueChallenge(c *gin.Context) {
+	c.Header("Cache-Control", "no-store")
+	c.JSON(http.StatusOK, h.pow.Issue(c.ClientIP()))
+}
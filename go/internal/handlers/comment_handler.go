@@ -0,0 +1,224 @@
+package handlers
+
+import (
+	"blog-api/internal/middleware"
+	"blog-api/internal/models"
+	"blog-api/internal/repository"
+	"blog-api/internal/services"
+	"blog-api/internal/utils"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+const maxCommentDepth = 5
+
+type CommentHandler struct {
+	repo        *repository.CommentRepository
+	blogRepo    *repository.BlogRepository
+	spamService *services.SpamService
+}
+
+func NewCommentHandler(spamService *services.SpamService) *CommentHandler {
+	return &CommentHandler{
+		repo:        repository.NewCommentRepository(),
+		blogRepo:    repository.NewBlogRepository(),
+		spamService: spamService,
+	}
+}
+
+func (h *CommentHandler) GetBlogComments(c *gin.Context) {
+	blog, err := h.blogRepo.GetByAnySlug(c.Param("slug"))
+	if err != nil || blog.Status != "published" {
+		c.JSON(http.StatusNotFound, gin.H{"error": "blog not found"})
+		return
+	}
+
+	comments, err := h.repo.GetApprovedByBlog(blog.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"comments": buildCommentTree(comments),
+		"total":    len(comments),
+	})
+}
+
+func (h *CommentHandler) CreateComment(c *gin.Context) {
+	blog, err := h.blogRepo.GetByAnySlug(c.Param("slug"))
+	if err != nil || blog.Status != "published" {
+		c.JSON(http.StatusNotFound, gin.H{"error": "blog not found"})
+		return
+	}
+
+	var req models.CreateCommentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	req.AuthorName = strings.TrimSpace(req.AuthorName)
+	req.Body = strings.TrimSpace(req.Body)
+	if req.AuthorName == "" || req.Body == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "author_name and body are required"})
+		return
+	}
+
+	comment := &models.Comment{
+		BlogID:      blog.ID,
+		AuthorName:  req.AuthorName,
+		AuthorEmail: strings.ToLower(strings.TrimSpace(req.AuthorEmail)),
+		Body:        req.Body,
+		BodyHTML:    utils.RenderMarkdownLite(req.Body),
+		Status:      models.CommentStatusPending,
+		VisitorHash: utils.ScopedVisitorHash("comments", c.ClientIP(), c.Request.UserAgent()),
+		ContentHash: services.ContentHash(req.Body),
+		SpamReasons: models.StringArray{},
+	}
+
+	if req.ParentID != nil {
+		parent, err := h.repo.GetByID(*req.ParentID)
+		if err != nil || parent.BlogID != blog.ID || parent.Status != models.CommentStatusApproved {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "parent comment not found"})
+			return
+		}
+		if parent.Depth+1 > maxCommentDepth {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "maximum reply depth reached"})
+			return
+		}
+		comment.ParentID = &parent.ID
+		comment.Depth = parent.Depth + 1
+	}
+
+	// The form counts as shown when its proof of work challenge was issued;
+	// unlike a client-supplied time, that one is signed.
+	submission := &services.SpamSubmission{
+		AuthorName:  comment.AuthorName,
+		AuthorEmail: comment.AuthorEmail,
+		Body:        comment.Body,
+		Honeypot:    req.Website,
+		SubmittedAt: time.Now(),
+	}
+	if issuedAt, ok := middleware.ChallengeIssuedAt(c); ok {
+		submission.RenderedAt = &issuedAt
+	}
+	verdict, err := h.spamService.Evaluate(submission)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	comment.SpamScore = verdict.Score
+	for _, signal := range verdict.Signals {
+		comment.SpamReasons = append(comment.SpamReasons, signal.Reason)
+	}
+	if verdict.IsSpam {
+		comment.Status = models.CommentStatusSpam
+	}
+
+	if err := h.repo.Create(comment); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Spam verdicts are deliberately not revealed to the submitter.
+	c.JSON(http.StatusAccepted, gin.H{
+		"id":      comment.ID,
+		"status":  models.CommentStatusPending,
+		"message": "comment submitted and awaiting moderation",
+	})
+}
+
+func (h *CommentHandler) ListComments(c *gin.Context) {
+	status := c.Query("status")
+	if status != "" && !models.IsValidCommentStatus(status) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid status. Allowed: pending, approved, spam, deleted"})
+		return
+	}
+
+	var blogID *uuid.UUID
+	if idStr := c.Query("blog_id"); idStr != "" {
+		id, err := uuid.Parse(idStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid blog_id format"})
+			return
+		}
+		blogID = &id
+	}
+
+	limit := parseLimit(c, 20, 100)
+	offset, err := strconv.Atoi(c.DefaultQuery("offset", "0"))
+	if err != nil || offset < 0 {
+		offset = 0
+	}
+
+	comments, total, err := h.repo.List(status, blogID, limit, offset)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"comments": comments,
+		"total":    total,
+		"limit":    limit,
+		"offset":   offset,
+	})
+}
+
+func (h *CommentHandler) ModerateComments(c *gin.Context) {
+	var req models.ModerateCommentsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if !models.IsValidCommentStatus(req.Status) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid status. Allowed: pending, approved, spam, deleted"})
+		return
+	}
+
+	updated, err := h.repo.UpdateStatus(req.IDs, req.Status)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"updated": updated,
+		"status":  req.Status,
+	})
+}
+
+// buildCommentTree nests approved comments under their parents. Replies whose
+// parent is no longer visible are promoted to the top level rather than lost.
+func buildCommentTree(comments []*models.Comment) []*models.PublicComment {
+	nodes := make(map[uuid.UUID]*models.PublicComment, len(comments))
+	for _, comment := range comments {
+		nodes[comment.ID] = &models.PublicComment{
+			ID:         comment.ID,
+			ParentID:   comment.ParentID,
+			AuthorName: comment.AuthorName,
+			BodyHTML:   comment.BodyHTML,
+			CreatedAt:  comment.CreatedAt,
+			Replies:    []*models.PublicComment{},
+		}
+	}
+
+	roots := []*models.PublicComment{}
+	for _, comment := range comments {
+		node := nodes[comment.ID]
+		if comment.ParentID != nil {
+			if parent, ok := nodes[*comment.ParentID]; ok {
+				parent.Replies = append(parent.Replies, node)
+				continue
+			}
+		}
+		roots = append(roots, node)
+	}
+	return roots
+}
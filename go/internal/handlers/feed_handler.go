@@ -0,0 +1,351 @@
+package handlers
+
+import (
+	"blog-api/internal/models"
+	"blog-api/internal/repository"
+	"blog-api/internal/services"
+	"blog-api/internal/utils"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type FeedHandler struct {
+	repo            *repository.BlogRepository
+	markdownService *services.MarkdownService
+	itemLimit       int
+}
+
+func NewFeedHandler(markdownService *services.MarkdownService) *FeedHandler {
+	itemLimit := 50
+	if parsed, err := strconv.Atoi(os.Getenv("FEED_ITEM_LIMIT")); err == nil && parsed > 0 {
+		itemLimit = parsed
+	}
+
+	return &FeedHandler{
+		repo:            repository.NewBlogRepository(),
+		markdownService: markdownService,
+		itemLimit:       itemLimit,
+	}
+}
+
+type feedOptions struct {
+	category    string
+	tag         string
+	fullContent bool
+}
+
+func parseFeedOptions(c *gin.Context) feedOptions {
+	return feedOptions{
+		category:    strings.TrimSpace(c.Query("category")),
+		tag:         strings.TrimSpace(c.Query("tag")),
+		fullContent: c.DefaultQuery("content", "full") != "excerpt",
+	}
+}
+
+func (o feedOptions) title() string {
+	title := utils.SiteTitle()
+	if o.category != "" {
+		title += " - " + o.category
+	}
+	if o.tag != "" {
+		title += " - #" + o.tag
+	}
+	return title
+}
+
+// loadFeed resolves the feed's blogs, or answers 304 and returns false when
+// the client's cached copy is still current.
+func (h *FeedHandler) loadFeed(c *gin.Context, format string, opts feedOptions) ([]*models.Blog, time.Time, bool) {
+	lastUpdated, total, err := h.repo.LastPublishedUpdate(opts.category, opts.tag)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return nil, time.Time{}, false
+	}
+
+	updated := time.Unix(0, 0).UTC()
+	if lastUpdated != nil {
+		updated = lastUpdated.UTC()
+	}
+
+	etag := fmt.Sprintf("%s|%s|%s|%t|%d|%d", format, opts.category, opts.tag, opts.fullContent, total, updated.UnixNano())
+	if notModified(c, etag, updated) {
+		return nil, time.Time{}, false
+	}
+
+	blogs, err := h.repo.GetPublished(opts.category, opts.tag, h.itemLimit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return nil, time.Time{}, false
+	}
+
+	return blogs, updated, true
+}
+
+func (h *FeedHandler) RSS(c *gin.Context) {
+	opts := parseFeedOptions(c)
+	blogs, updated, ok := h.loadFeed(c, "rss", opts)
+	if !ok {
+		return
+	}
+
+	feed := models.RSSFeed{
+		Version: "2.0",
+		AtomNS:  "http://www.w3.org/2005/Atom",
+		Content: "http://purl.org/rss/1.0/modules/content/",
+		Channel: models.RSSChannel{
+			Title:         opts.title(),
+			Link:          utils.SiteURL(),
+			Description:   utils.SiteDescription(),
+			Language:      os.Getenv("SITE_LANGUAGE"),
+			LastBuildDate: updated.Format(time.RFC1123Z),
+			AtomLink:      models.AtomLink{Href: feedURL(feedBaseURL(), c, opts), Rel: "self", Type: "application/rss+xml"},
+			Items:         make([]models.RSSItem, 0, len(blogs)),
+		},
+	}
+
+	for _, blog := range blogs {
+		link := utils.PostURL(blog.Slug)
+		item := models.RSSItem{
+			Title:       blog.Title,
+			Link:        link,
+			GUID:        models.RSSGUID{IsPermaLink: "false", Value: blog.ID.String()},
+			PubDate:     publishedAt(blog).Format(time.RFC1123Z),
+			Description: feedSummary(blog),
+			Categories:  feedCategories(blog),
+		}
+		if opts.fullContent {
+			item.ContentEncoded = &models.CDATA{Value: h.feedContent(blog)}
+		}
+		if blog.FeaturedImage != nil && *blog.FeaturedImage != "" {
+			item.Enclosure = &models.RSSEnclosure{
+				URL:    *blog.FeaturedImage,
+				Length: "0",
+				Type:   imageMIMEType(*blog.FeaturedImage),
+			}
+		}
+		feed.Channel.Items = append(feed.Channel.Items, item)
+	}
+
+	writeXML(c, "application/rss+xml; charset=utf-8", feed)
+}
+
+func (h *FeedHandler) Atom(c *gin.Context) {
+	opts := parseFeedOptions(c)
+	blogs, updated, ok := h.loadFeed(c, "atom", opts)
+	if !ok {
+		return
+	}
+
+	feed := models.AtomFeed{
+		Title:    opts.title(),
+		Subtitle: utils.SiteDescription(),
+		ID:       feedURL(utils.SiteURL(), c, opts),
+		Updated:  updated.Format(time.RFC3339),
+		Links: []models.AtomLink{
+			{Href: feedURL(feedBaseURL(), c, opts), Rel: "self", Type: "application/atom+xml"},
+			{Href: utils.SiteURL(), Rel: "alternate", Type: "text/html"},
+		},
+		Entries: make([]models.AtomEntry, 0, len(blogs)),
+	}
+	if author := utils.SiteAuthor(); author != "" {
+		feed.Author = &models.AtomPerson{Name: author}
+	}
+
+	for _, blog := range blogs {
+		entry := models.AtomEntry{
+			Title:     blog.Title,
+			ID:        "urn:uuid:" + blog.ID.String(),
+			Links:     []models.AtomLink{{Href: utils.PostURL(blog.Slug), Rel: "alternate", Type: "text/html"}},
+			Published: publishedAt(blog).Format(time.RFC3339),
+			Updated:   blog.UpdatedAt.UTC().Format(time.RFC3339),
+			Summary:   &models.AtomText{Type: "text", Value: feedSummary(blog)},
+		}
+		if opts.fullContent {
+			entry.Content = &models.AtomText{Type: "html", Value: h.feedContent(blog)}
+		}
+		for _, category := range feedCategories(blog) {
+			entry.Categories = append(entry.Categories, models.AtomCategory{Term: category})
+		}
+		if blog.FeaturedImage != nil && *blog.FeaturedImage != "" {
+			entry.Links = append(entry.Links, models.AtomLink{
+				Href: *blog.FeaturedImage,
+				Rel:  "enclosure",
+				Type: imageMIMEType(*blog.FeaturedImage),
+			})
+		}
+		feed.Entries = append(feed.Entries, entry)
+	}
+
+	writeXML(c, "application/atom+xml; charset=utf-8", feed)
+}
+
+func (h *FeedHandler) JSONFeed(c *gin.Context) {
+	opts := parseFeedOptions(c)
+	blogs, _, ok := h.loadFeed(c, "json", opts)
+	if !ok {
+		return
+	}
+
+	feed := models.JSONFeed{
+		Version:     "https://jsonfeed.org/version/1.1",
+		Title:       opts.title(),
+		HomePageURL: utils.SiteURL(),
+		FeedURL:     feedURL(feedBaseURL(), c, opts),
+		Description: utils.SiteDescription(),
+		Items:       make([]models.JSONFeedItem, 0, len(blogs)),
+	}
+	if author := utils.SiteAuthor(); author != "" {
+		feed.Authors = []models.JSONFeedAuthor{{Name: author}}
+	}
+
+	for _, blog := range blogs {
+		item := models.JSONFeedItem{
+			ID:            blog.ID.String(),
+			URL:           utils.PostURL(blog.Slug),
+			Title:         blog.Title,
+			Summary:       feedSummary(blog),
+			DatePublished: publishedAt(blog).Format(time.RFC3339),
+			DateModified:  blog.UpdatedAt.UTC().Format(time.RFC3339),
+			Tags:          feedCategories(blog),
+		}
+		if opts.fullContent {
+			item.ContentHTML = h.feedContent(blog)
+		} else {
+			item.ContentText = feedSummary(blog)
+		}
+		if blog.FeaturedImage != nil && *blog.FeaturedImage != "" {
+			item.Image = *blog.FeaturedImage
+			item.Attachments = []models.JSONFeedAttachment{{
+				URL:      *blog.FeaturedImage,
+				MIMEType: imageMIMEType(*blog.FeaturedImage),
+			}}
+		}
+		feed.Items = append(feed.Items, item)
+	}
+
+	c.Header("Content-Type", "application/feed+json; charset=utf-8")
+	c.JSON(http.StatusOK, feed)
+}
+
+func publishedAt(blog *models.Blog) time.Time {
+	if blog.PublishedAt != nil {
+		return blog.PublishedAt.UTC()
+	}
+	return blog.CreatedAt.UTC()
+}
+
+func feedSummary(blog *models.Blog) string {
+	if blog.Excerpt != nil && *blog.Excerpt != "" {
+		return *blog.Excerpt
+	}
+	return utils.Truncate(blog.Content, 280)
+}
+
+func (h *FeedHandler) feedContent(blog *models.Blog) string {
+	rendered, err := h.markdownService.Render(blog.Content)
+	if err != nil {
+		return blog.Content
+	}
+	return rendered.HTML
+}
+
+func feedCategories(blog *models.Blog) []string {
+	categories := []string{}
+	if blog.Category != "" {
+		categories = append(categories, blog.Category)
+	}
+	for _, tag := range blog.Tags {
+		if tag != "" {
+			categories = append(categories, tag)
+		}
+	}
+	return categories
+}
+
+func imageMIMEType(imageURL string) string {
+	ext := strings.ToLower(path.Ext(strings.SplitN(imageURL, "?", 2)[0]))
+	if mimeType := mime.TypeByExtension(ext); strings.HasPrefix(mimeType, "image/") {
+		return mimeType
+	}
+	return "image/jpeg"
+}
+
+// feedURL identifies a feed by its path and the options that shape it, so
+// the same feed keeps the same URL whatever host or query order it was
+// requested with.
+func feedURL(base string, c *gin.Context, opts feedOptions) string {
+	query := url.Values{}
+	if opts.category != "" {
+		query.Set("category", opts.category)
+	}
+	if opts.tag != "" {
+		query.Set("tag", opts.tag)
+	}
+	if !opts.fullContent {
+		query.Set("content", "excerpt")
+	}
+	if len(query) == 0 {
+		return base + c.Request.URL.Path
+	}
+	return base + c.Request.URL.Path + "?" + query.Encode()
+}
+
+// feedBaseURL is where the API serves the feed files, falling back to the
+// site for setups that proxy them there.
+func feedBaseURL() string {
+	if base := utils.APIURL(); base != "" {
+		return base
+	}
+	return utils.SiteURL()
+}
+
+func writeXML(c *gin.Context, contentType string, v interface{}) {
+	body, err := xml.MarshalIndent(v, "", "  ")
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to render feed"})
+		return
+	}
+	c.Data(http.StatusOK, contentType, append([]byte(xml.Header), body...))
+}
+
+// notModified sets caching headers for a generated document and answers the
+// request with 304 when If-None-Match or If-Modified-Since already match.
+func notModified(c *gin.Context, version string, lastModified time.Time) bool {
+	sum := sha256.Sum256([]byte(version))
+	etag := `"` + hex.EncodeToString(sum[:16]) + `"`
+
+	c.Header("ETag", etag)
+	c.Header("Last-Modified", lastModified.UTC().Format(http.TimeFormat))
+	c.Header("Cache-Control", "public, max-age=300")
+
+	if match := c.GetHeader("If-None-Match"); match != "" {
+		for _, candidate := range strings.Split(match, ",") {
+			candidate = strings.TrimSpace(candidate)
+			if candidate == etag || candidate == "*" || candidate == "W/"+etag {
+				c.Status(http.StatusNotModified)
+				return true
+			}
+		}
+		return false
+	}
+
+	if since := c.GetHeader("If-Modified-Since"); since != "" {
+		if t, err := http.ParseTime(since); err == nil && !lastModified.Truncate(time.Second).After(t) {
+			c.Status(http.StatusNotModified)
+			return true
+		}
+	}
+	return false
+}
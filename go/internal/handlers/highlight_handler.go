@@ -0,0 +1,34 @@
+package handlers
+
+import (
+	"blog-api/internal/services"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type HighlightHandler struct {
+	stylesheets map[string]string
+}
+
+func NewHighlightHandler() *HighlightHandler {
+	stylesheets := make(map[string]string)
+	for _, theme := range []string{"light", "dark", "auto"} {
+		if css, err := services.HighlightCSS(theme); err == nil {
+			stylesheets[theme] = css
+		}
+	}
+
+	return &HighlightHandler{stylesheets: stylesheets}
+}
+
+func (h *HighlightHandler) Stylesheet(c *gin.Context) {
+	css, ok := h.stylesheets[c.DefaultQuery("theme", "auto")]
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid theme. Allowed: light, dark, auto"})
+		return
+	}
+
+	c.Header("Cache-Control", "public, max-age=86400")
+	c.Data(http.StatusOK, "text/css; charset=utf-8", []byte(css))
+}
@@ -0,0 +1,345 @@
+package handlers
+
+import (
+	"blog-api/internal/middleware"
+	"blog-api/internal/models"
+	"blog-api/internal/repository"
+	"blog-api/internal/services"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type MediaHandler struct {
+	repo   *repository.MediaRepository
+	assets *services.AssetService
+	images *services.ImageService
+	direct *services.DirectUploadService
+}
+
+func NewMediaHandler(assets *services.AssetService, images *services.ImageService, direct *services.DirectUploadService) *MediaHandler {
+	return &MediaHandler{
+		repo:   repository.NewMediaRepository(),
+		assets: assets,
+		images: images,
+		direct: direct,
+	}
+}
+
+func (h *MediaHandler) UploadMedia(c *gin.Context) {
+	if !parseUploadForm(c) {
+		return
+	}
+	file, err := c.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "file is required"})
+		return
+	}
+
+	image, ok := prepareImageUpload(c, h.images, file)
+	if !ok {
+		return
+	}
+	defer image.Close()
+
+	uploadedBy := strings.TrimSpace(c.PostForm("uploaded_by"))
+	if uploadedBy == "" {
+		uploadedBy = "api"
+	}
+
+	h.createMedia(c, uuid.New(), image, filepath.Base(file.Filename), c.PostForm("alt_text"), uploadedBy)
+}
+
+// createMedia stores a validated image with its variants and records it in
+// the media library, writing the response either way. Files go under fresh
+// keys rather than the media ID: when two confirmations of the same upload
+// race, the one whose record loses only removes its own files.
+func (h *MediaHandler) createMedia(c *gin.Context, id uuid.UUID, image *services.PreparedImage, filename, altText, uploadedBy string) {
+	uploaded, err := uploadImage(c.Request.Context(), h.assets, h.images, image, services.MediaPrefix+uuid.New().String(), models.AssetOwnerMedia, id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("failed to upload file: %v", err)})
+		return
+	}
+
+	media := &models.Media{
+		ID:            id,
+		URL:           uploaded.URL,
+		PublicID:      uploaded.Keys[0],
+		Storage:       h.assets.Storage().Name(),
+		Filename:      filename,
+		MimeType:      image.MimeType,
+		Size:          uploaded.Size,
+		Width:         image.Width,
+		Height:        image.Height,
+		Variants:      uploaded.Variants,
+		Blurhash:      uploaded.Placeholder.Blurhash,
+		DominantColor: uploaded.Placeholder.DominantColor,
+		AltText:       strings.TrimSpace(altText),
+		UploadedBy:    uploadedBy,
+	}
+
+	if err := h.repo.Create(media); err != nil {
+		// Don't leave objects behind that nothing references.
+		releaseUpload(c.Request.Context(), h.assets, uploaded)
+		if errors.Is(err, repository.ErrMediaExists) {
+			c.JSON(http.StatusConflict, gin.H{"error": "upload has already been confirmed"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	media.Responsive = media.Variants.Responsive()
+
+	c.JSON(http.StatusCreated, media)
+}
+
+type issueUploadRequest struct {
+	Filename    string `json:"filename" binding:"required"`
+	ContentType string `json:"content_type" binding:"required"`
+	Size        int64  `json:"size"`
+	UploadedBy  string `json:"uploaded_by"`
+}
+
+// IssueUpload hands out parameters for uploading a file straight to storage,
+// plus the token to confirm it with once the upload has finished.
+func (h *MediaHandler) IssueUpload(c *gin.Context) {
+	var req issueUploadRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if req.Size < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "size must not be negative"})
+		return
+	}
+	uploadedBy := strings.TrimSpace(req.UploadedBy)
+	if uploadedBy == "" {
+		uploadedBy = "api"
+	}
+
+	ticket, err := h.direct.Issue(c.Request.Context(), req.Filename, req.ContentType, req.Size, uploadedBy)
+	if err != nil {
+		if errors.Is(err, services.ErrDirectUploadUnsupported) {
+			c.JSON(http.StatusNotImplemented, gin.H{"error": err.Error()})
+			return
+		}
+		writeImageError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, ticket)
+}
+
+type confirmUploadRequest struct {
+	Token   string `json:"token" binding:"required"`
+	AltText string `json:"alt_text"`
+}
+
+// ConfirmUpload validates a file that was uploaded directly to storage and
+// registers it in the media library. The image is processed exactly like
+// one sent to UploadMedia, then the staged file is removed.
+func (h *MediaHandler) ConfirmUpload(c *gin.Context) {
+	var req confirmUploadRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	claims, err := h.direct.Verify(req.Token)
+	if err != nil {
+		status := http.StatusBadRequest
+		if errors.Is(err, services.ErrUploadTokenExpired) {
+			status = http.StatusGone
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+	if _, err := h.repo.GetByID(claims.ID); err == nil {
+		c.JSON(http.StatusConflict, gin.H{"error": "upload has already been confirmed"})
+		return
+	}
+
+	storage := h.assets.Storage()
+	ctx := c.Request.Context()
+	body, err := storage.Open(ctx, claims.Key)
+	if err != nil {
+		if errors.Is(err, services.ErrObjectNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "no file has been uploaded for this token"})
+			return
+		}
+		c.JSON(http.StatusBadGateway, gin.H{"error": fmt.Sprintf("failed to read uploaded file: %v", err)})
+		return
+	}
+	image, err := h.images.Prepare(body)
+	body.Close()
+
+	removeStaged := func() {
+		if err := storage.Delete(context.WithoutCancel(ctx), claims.Key); err != nil && !errors.Is(err, services.ErrObjectNotFound) {
+			log.Printf("Warning: failed to delete staged upload %s: %v", claims.Key, err)
+		}
+	}
+	if err != nil {
+		removeStaged()
+		writeImageError(c, err)
+		return
+	}
+	defer image.Close()
+
+	h.createMedia(c, claims.ID, image, claims.Filename, req.AltText, claims.UploadedBy)
+	if c.Writer.Status() == http.StatusCreated {
+		removeStaged()
+	}
+}
+
+// ReceiveUpload accepts direct uploads for local storage, which has no
+// upload endpoint of its own. The body is streamed to disk and cut off at
+// the size signed into the URL.
+func (h *MediaHandler) ReceiveUpload(c *gin.Context) {
+	local, ok := h.assets.Storage().(*services.LocalStorage)
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "not found"})
+		return
+	}
+
+	key := strings.TrimPrefix(c.Param("key"), "/")
+	maxBytes, err := local.VerifyUpload(key, c.Request.URL.Query(), c.GetHeader("Content-Type"))
+	if err != nil {
+		status := http.StatusForbidden
+		if !errors.Is(err, services.ErrUploadTokenInvalid) && !errors.Is(err, services.ErrUploadTokenExpired) {
+			status = http.StatusUnsupportedMediaType
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+
+	if c.Request.ContentLength > maxBytes {
+		middleware.BodyTooLarge(c, maxBytes)
+		return
+	}
+
+	body := http.MaxBytesReader(c.Writer, c.Request.Body, maxBytes)
+	if _, err := local.Put(c.Request.Context(), key, body, c.Request.ContentLength, c.ContentType()); err != nil {
+		var maxErr *http.MaxBytesError
+		if errors.As(err, &maxErr) {
+			middleware.BodyTooLarge(c, maxBytes)
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+func (h *MediaHandler) ListMedia(c *gin.Context) {
+	limit := parseLimit(c, 20, 100)
+	offset, err := strconv.Atoi(c.DefaultQuery("offset", "0"))
+	if err != nil || offset < 0 {
+		offset = 0
+	}
+
+	media, total, err := h.repo.List(strings.TrimSpace(c.Query("q")), strings.TrimSpace(c.Query("mime_type")), limit, offset)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"media":  media,
+		"total":  total,
+		"limit":  limit,
+		"offset": offset,
+	})
+}
+
+func (h *MediaHandler) GetMedia(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id format"})
+		return
+	}
+
+	media, err := h.repo.GetByID(id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "media not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, media)
+}
+
+func (h *MediaHandler) UpdateMedia(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id format"})
+		return
+	}
+
+	var req models.UpdateMediaRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if req.AltText == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "no fields to update"})
+		return
+	}
+
+	if err := h.repo.Update(id, map[string]interface{}{"alt_text": strings.TrimSpace(*req.AltText)}); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	media, err := h.repo.GetByID(id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch updated media"})
+		return
+	}
+
+	c.JSON(http.StatusOK, media)
+}
+
+func (h *MediaHandler) DeleteMedia(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id format"})
+		return
+	}
+
+	media, err := h.repo.GetByID(id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "media not found"})
+		return
+	}
+
+	storageName := h.assets.Storage().Name()
+	if media.Storage != storageName {
+		c.JSON(http.StatusConflict, gin.H{"error": fmt.Sprintf("media is kept in %s storage, but %s is configured", media.Storage, storageName)})
+		return
+	}
+
+	err = h.assets.Release(c.Request.Context(), media.PublicID)
+	if err == nil {
+		// Variants are tracked under the same owner.
+		err = h.assets.ReleaseOwner(c.Request.Context(), models.AssetOwnerMedia, id)
+	}
+	if err != nil {
+		c.JSON(http.StatusBadGateway, gin.H{"error": fmt.Sprintf("failed to delete file from storage: %v", err)})
+		return
+	}
+
+	if err := h.repo.Delete(id); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "media deleted successfully"})
+}
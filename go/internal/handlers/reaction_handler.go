@@ -0,0 +1,172 @@
+package handlers
+
+import (
+	"blog-api/internal/models"
+	"blog-api/internal/repository"
+	"blog-api/internal/utils"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type ReactionHandler struct {
+	repo        *repository.ReactionRepository
+	blogRepo    *repository.BlogRepository
+	hourlyLimit int64
+}
+
+func NewReactionHandler() *ReactionHandler {
+	hourlyLimit := int64(60)
+	if parsed, err := strconv.ParseInt(os.Getenv("REACTION_HOURLY_LIMIT"), 10, 64); err == nil && parsed > 0 {
+		hourlyLimit = parsed
+	}
+
+	h := &ReactionHandler{
+		repo:        repository.NewReactionRepository(),
+		blogRepo:    repository.NewBlogRepository(),
+		hourlyLimit: hourlyLimit,
+	}
+	go h.purgeEvents()
+	return h
+}
+
+// purgeEvents drops reaction events once they fall out of the hourly
+// window.
+func (h *ReactionHandler) purgeEvents() {
+	for {
+		if _, err := h.repo.PurgeEvents(time.Now().Add(-time.Hour)); err != nil {
+			log.Printf("Warning: %v", err)
+		}
+		time.Sleep(time.Hour)
+	}
+}
+
+func reactionVisitorHash(c *gin.Context) string {
+	return utils.ScopedVisitorHash("reactions", c.ClientIP(), c.Request.UserAgent())
+}
+
+func (h *ReactionHandler) GetReactions(c *gin.Context) {
+	blog, err := h.blogRepo.GetByAnySlug(c.Param("slug"))
+	if err != nil || blog.Status != "published" {
+		c.JSON(http.StatusNotFound, gin.H{"error": "blog not found"})
+		return
+	}
+
+	summary, err := h.summary(blog, reactionVisitorHash(c))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, summary)
+}
+
+func (h *ReactionHandler) AddReaction(c *gin.Context) {
+	blog, err := h.blogRepo.GetByAnySlug(c.Param("slug"))
+	if err != nil || blog.Status != "published" {
+		c.JSON(http.StatusNotFound, gin.H{"error": "blog not found"})
+		return
+	}
+
+	var req models.ReactionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if !models.IsValidReactionType(req.Type) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid reaction type", "allowed": models.ReactionTypes})
+		return
+	}
+
+	if utils.DeviceClass(c.Request.UserAgent()) == "bot" {
+		c.JSON(http.StatusForbidden, gin.H{"error": "reactions are not accepted from automated clients"})
+		return
+	}
+
+	visitorHash := reactionVisitorHash(c)
+	recent, err := h.repo.CountRecentByVisitor(visitorHash, time.Now().Add(-time.Hour))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if recent >= h.hourlyLimit {
+		c.JSON(http.StatusTooManyRequests, gin.H{"error": "reaction limit reached. Please try again later."})
+		return
+	}
+
+	created, err := h.repo.Add(&models.Reaction{
+		BlogID:      blog.ID,
+		VisitorHash: visitorHash,
+		Type:        req.Type,
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	summary, err := h.summary(blog, visitorHash)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	status := http.StatusCreated
+	if !created {
+		status = http.StatusOK
+	}
+	c.JSON(status, summary)
+}
+
+func (h *ReactionHandler) RemoveReaction(c *gin.Context) {
+	blog, err := h.blogRepo.GetByAnySlug(c.Param("slug"))
+	if err != nil || blog.Status != "published" {
+		c.JSON(http.StatusNotFound, gin.H{"error": "blog not found"})
+		return
+	}
+
+	reactionType := c.Param("type")
+	if !models.IsValidReactionType(reactionType) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid reaction type"})
+		return
+	}
+
+	visitorHash := reactionVisitorHash(c)
+	if err := h.repo.Remove(blog.ID, visitorHash, reactionType); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	summary, err := h.summary(blog, visitorHash)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, summary)
+}
+
+func (h *ReactionHandler) summary(blog *models.Blog, visitorHash string) (*models.ReactionSummary, error) {
+	counts, err := h.repo.CountsForBlogs([]uuid.UUID{blog.ID})
+	if err != nil {
+		return nil, err
+	}
+	reacted, err := h.repo.VisitorReactions(blog.ID, visitorHash)
+	if err != nil {
+		return nil, err
+	}
+
+	summary := &models.ReactionSummary{
+		Counts:  make(map[string]int64, len(models.ReactionTypes)),
+		Reacted: reacted,
+	}
+	for reactionType := range models.ReactionTypes {
+		summary.Counts[reactionType] = counts[blog.ID][reactionType]
+		summary.Total += counts[blog.ID][reactionType]
+	}
+	return summary, nil
+}
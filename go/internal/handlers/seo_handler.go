@@ -0,0 +1,251 @@
+package handlers
+
+import (
+	"blog-api/internal/models"
+	"blog-api/internal/repository"
+	"blog-api/internal/services"
+	"blog-api/internal/utils"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	maxMetaDescriptionLength = 160
+	maxSEOURLLength          = 2048
+)
+
+var twitterCardTypes = map[string]bool{"summary": true, "summary_large_image": true}
+
+type SEOHandler struct {
+	repo            *repository.BlogRepository
+	markdownService *services.MarkdownService
+	ogImageService  *services.OGImageService
+}
+
+func NewSEOHandler(markdownService *services.MarkdownService, ogImageService *services.OGImageService) *SEOHandler {
+	return &SEOHandler{
+		repo:            repository.NewBlogRepository(),
+		markdownService: markdownService,
+		ogImageService:  ogImageService,
+	}
+}
+
+func (h *SEOHandler) GetBlogMeta(c *gin.Context) {
+	blog, err := h.repo.GetByAnySlug(c.Param("slug"))
+	if err != nil || blog.Status != "published" {
+		c.JSON(http.StatusNotFound, gin.H{"error": "blog not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, h.buildMetadata(blog))
+}
+
+func (h *SEOHandler) GetBlogOGImage(c *gin.Context) {
+	blog, err := h.repo.GetByAnySlug(c.Param("slug"))
+	if err != nil || blog.Status != "published" {
+		c.JSON(http.StatusNotFound, gin.H{"error": "blog not found"})
+		return
+	}
+
+	if h.ogImageService == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "image generation is not available"})
+		return
+	}
+
+	data, key, err := h.ogImageService.Render(services.OGCard{
+		Title:    blog.Title,
+		Category: blog.Category,
+		Author:   utils.SiteAuthor(),
+		Site:     siteBranding(),
+		Version:  blog.UpdatedAt.UTC().Format(time.RFC3339Nano),
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	etag := `"` + key[:32] + `"`
+	c.Header("ETag", etag)
+	c.Header("Cache-Control", "public, max-age=86400")
+	if c.GetHeader("If-None-Match") == etag {
+		c.Status(http.StatusNotModified)
+		return
+	}
+	c.Data(http.StatusOK, "image/png", data)
+}
+
+func siteBranding() string {
+	if u, err := url.Parse(utils.SiteURL()); err == nil && u.Host != "" {
+		return u.Host
+	}
+	return utils.SiteTitle()
+}
+
+func (h *SEOHandler) buildMetadata(blog *models.Blog) *models.PageMetadata {
+	description := h.description(blog)
+	canonical := utils.PostURL(blog.Slug)
+	if blog.CanonicalURL != nil && *blog.CanonicalURL != "" {
+		canonical = *blog.CanonicalURL
+	}
+
+	images := []models.OpenGraphImage{}
+	twitterImages := []string{}
+	if image := ogImageURL(blog); image != "" {
+		images = append(images, models.OpenGraphImage{URL: image, Alt: blog.Title})
+		twitterImages = append(twitterImages, image)
+	} else if h.ogImageService != nil && utils.APIURL() != "" {
+		image := fmt.Sprintf("%s/api/v1/public/blogs/%s/og-image.png?v=%d",
+			utils.APIURL(), url.PathEscape(blog.Slug), blog.UpdatedAt.Unix())
+		images = append(images, models.OpenGraphImage{
+			URL:    image,
+			Width:  services.OGImageWidth,
+			Height: services.OGImageHeight,
+			Alt:    blog.Title,
+		})
+		twitterImages = append(twitterImages, image)
+	}
+
+	card := blog.TwitterCard
+	if card == "" {
+		card = "summary"
+		if len(images) > 0 {
+			card = "summary_large_image"
+		}
+	}
+
+	og := models.OpenGraph{
+		Title:        blog.Title,
+		Description:  description,
+		URL:          canonical,
+		SiteName:     utils.SiteTitle(),
+		Type:         "article",
+		ModifiedTime: blog.UpdatedAt.UTC().Format(time.RFC3339),
+		Section:      blog.Category,
+		Tags:         blog.Tags,
+		Images:       images,
+	}
+	if blog.PublishedAt != nil {
+		og.PublishedTime = blog.PublishedAt.UTC().Format(time.RFC3339)
+	}
+	if author := utils.SiteAuthor(); author != "" {
+		og.Authors = []string{author}
+	}
+
+	meta := &models.PageMetadata{
+		Title:       blog.Title,
+		Description: description,
+		Alternates:  models.MetadataAlternate{Canonical: canonical},
+		Robots:      models.MetadataRobots{Index: !blog.NoIndex, Follow: !blog.NoIndex},
+		OpenGraph:   og,
+		Twitter: models.TwitterCard{
+			Card:        card,
+			Title:       blog.Title,
+			Description: description,
+			Images:      twitterImages,
+		},
+	}
+	meta.MetaTags = metaTags(meta)
+	return meta
+}
+
+func (h *SEOHandler) description(blog *models.Blog) string {
+	if blog.MetaDescription != nil && *blog.MetaDescription != "" {
+		return *blog.MetaDescription
+	}
+	if blog.Excerpt != nil && *blog.Excerpt != "" {
+		return utils.Truncate(*blog.Excerpt, maxMetaDescriptionLength)
+	}
+	return utils.Truncate(h.markdownService.PlainText(blog.Content), maxMetaDescriptionLength)
+}
+
+func ogImageURL(blog *models.Blog) string {
+	if blog.OGImage != nil && *blog.OGImage != "" {
+		return *blog.OGImage
+	}
+	if blog.FeaturedImage != nil && *blog.FeaturedImage != "" {
+		return *blog.FeaturedImage
+	}
+	return ""
+}
+
+func metaTags(meta *models.PageMetadata) []models.MetaTag {
+	robots := "index, follow"
+	if !meta.Robots.Index {
+		robots = "noindex, nofollow"
+	}
+
+	tags := []models.MetaTag{
+		{Name: "description", Content: meta.Description},
+		{Name: "robots", Content: robots},
+		{Property: "og:title", Content: meta.OpenGraph.Title},
+		{Property: "og:description", Content: meta.OpenGraph.Description},
+		{Property: "og:url", Content: meta.OpenGraph.URL},
+		{Property: "og:type", Content: meta.OpenGraph.Type},
+		{Property: "article:modified_time", Content: meta.OpenGraph.ModifiedTime},
+		{Name: "twitter:card", Content: meta.Twitter.Card},
+		{Name: "twitter:title", Content: meta.Twitter.Title},
+		{Name: "twitter:description", Content: meta.Twitter.Description},
+	}
+	if meta.OpenGraph.SiteName != "" {
+		tags = append(tags, models.MetaTag{Property: "og:site_name", Content: meta.OpenGraph.SiteName})
+	}
+	if meta.OpenGraph.PublishedTime != "" {
+		tags = append(tags, models.MetaTag{Property: "article:published_time", Content: meta.OpenGraph.PublishedTime})
+	}
+	if meta.OpenGraph.Section != "" {
+		tags = append(tags, models.MetaTag{Property: "article:section", Content: meta.OpenGraph.Section})
+	}
+	for _, tag := range meta.OpenGraph.Tags {
+		tags = append(tags, models.MetaTag{Property: "article:tag", Content: tag})
+	}
+	for _, image := range meta.OpenGraph.Images {
+		tags = append(tags, models.MetaTag{Property: "og:image", Content: image.URL})
+		if image.Width > 0 && image.Height > 0 {
+			tags = append(tags,
+				models.MetaTag{Property: "og:image:width", Content: fmt.Sprint(image.Width)},
+				models.MetaTag{Property: "og:image:height", Content: fmt.Sprint(image.Height)},
+			)
+		}
+		tags = append(tags, models.MetaTag{Property: "og:image:alt", Content: image.Alt})
+	}
+	for _, image := range meta.Twitter.Images {
+		tags = append(tags, models.MetaTag{Name: "twitter:image", Content: image})
+	}
+	return tags
+}
+
+func validateSEOFields(description, canonicalURL, ogImage, twitterCard *string) error {
+	if description != nil && utf8.RuneCountInString(*description) > maxMetaDescriptionLength {
+		return fmt.Errorf("meta_description must be at most %d characters", maxMetaDescriptionLength)
+	}
+	if canonicalURL != nil && *canonicalURL != "" {
+		if err := validateAbsoluteURL("canonical_url", *canonicalURL); err != nil {
+			return err
+		}
+	}
+	if ogImage != nil && *ogImage != "" {
+		if err := validateAbsoluteURL("og_image", *ogImage); err != nil {
+			return err
+		}
+	}
+	if twitterCard != nil && *twitterCard != "" && !twitterCardTypes[*twitterCard] {
+		return fmt.Errorf("twitter_card must be one of: summary, summary_large_image")
+	}
+	return nil
+}
+
+func validateAbsoluteURL(field, value string) error {
+	if len(value) > maxSEOURLLength {
+		return fmt.Errorf("%s must be at most %d characters", field, maxSEOURLLength)
+	}
+	u, err := url.Parse(value)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("%s must be an absolute http(s) URL", field)
+	}
+	return nil
+}
@@ -0,0 +1,179 @@
+package handlers
+
+import (
+	"blog-api/internal/models"
+	"blog-api/internal/repository"
+	"blog-api/internal/utils"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// sitemapMaxURLs is the per-file limit from the sitemaps.org protocol.
+const sitemapMaxURLs = 50000
+
+type SitemapHandler struct {
+	repo        *repository.BlogRepository
+	maxURLs     int
+	staticPaths []string
+}
+
+func NewSitemapHandler() *SitemapHandler {
+	staticPaths := []string{"/", "/blogs"}
+	if paths := os.Getenv("SITEMAP_STATIC_PATHS"); paths != "" {
+		staticPaths = splitList(paths)
+	}
+
+	return &SitemapHandler{
+		repo:        repository.NewBlogRepository(),
+		maxURLs:     sitemapMaxURLs,
+		staticPaths: staticPaths,
+	}
+}
+
+func (h *SitemapHandler) Sitemap(c *gin.Context) {
+	lastUpdated, total, ok := h.sitemapVersion(c, "sitemap")
+	if !ok {
+		return
+	}
+
+	if int(total)+len(h.staticPaths) <= h.maxURLs {
+		h.writeURLSet(c, 1)
+		return
+	}
+
+	pages := (int(total) + len(h.staticPaths) + h.maxURLs - 1) / h.maxURLs
+	index := models.SitemapIndex{Sitemaps: make([]models.SitemapEntry, 0, pages)}
+	for page := 1; page <= pages; page++ {
+		index.Sitemaps = append(index.Sitemaps, models.SitemapEntry{
+			Loc:     fmt.Sprintf("%s/sitemaps/%d.xml", sitemapBaseURL(), page),
+			LastMod: lastUpdated.Format(time.RFC3339),
+		})
+	}
+
+	writeXML(c, "application/xml; charset=utf-8", index)
+}
+
+func (h *SitemapHandler) SitemapPage(c *gin.Context) {
+	page, err := strconv.Atoi(strings.TrimSuffix(c.Param("page"), ".xml"))
+	if err != nil || page < 1 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "sitemap not found"})
+		return
+	}
+
+	_, total, ok := h.sitemapVersion(c, "sitemap-"+strconv.Itoa(page))
+	if !ok {
+		return
+	}
+	if (page-1)*h.maxURLs >= int(total)+len(h.staticPaths) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "sitemap not found"})
+		return
+	}
+
+	h.writeURLSet(c, page)
+}
+
+func (h *SitemapHandler) Robots(c *gin.Context) {
+	var b strings.Builder
+	b.WriteString("User-agent: *\n")
+	// Social cards are fetched by crawlers such as Twitterbot from below /api/.
+	b.WriteString("Allow: /api/v1/public/blogs/*/og-image.png\n")
+
+	disallow := []string{"/api/"}
+	if paths := os.Getenv("ROBOTS_DISALLOW"); paths != "" {
+		disallow = append(disallow, splitList(paths)...)
+	}
+	for _, path := range disallow {
+		b.WriteString("Disallow: " + path + "\n")
+	}
+	b.WriteString("Allow: /\n\n")
+	b.WriteString("Sitemap: " + sitemapBaseURL() + "/sitemap.xml\n")
+
+	c.Header("Cache-Control", "public, max-age=3600")
+	c.Data(http.StatusOK, "text/plain; charset=utf-8", []byte(b.String()))
+}
+
+func (h *SitemapHandler) sitemapVersion(c *gin.Context, name string) (time.Time, int64, bool) {
+	lastUpdated, total, err := h.repo.SitemapVersion()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return time.Time{}, 0, false
+	}
+
+	updated := time.Unix(0, 0).UTC()
+	if lastUpdated != nil {
+		updated = lastUpdated.UTC()
+	}
+
+	if notModified(c, fmt.Sprintf("%s|%d|%d", name, total, updated.UnixNano()), updated) {
+		return time.Time{}, 0, false
+	}
+	return updated, total, true
+}
+
+// writeURLSet renders one sitemap file. Static pages lead the first file, so
+// blog offsets are shifted by their count.
+func (h *SitemapHandler) writeURLSet(c *gin.Context, page int) {
+	start := (page - 1) * h.maxURLs
+	urls := make([]models.SitemapURL, 0)
+
+	for i, path := range h.staticPaths {
+		if i >= start && i < start+h.maxURLs {
+			urls = append(urls, models.SitemapURL{
+				Loc:        utils.SiteURL() + path,
+				ChangeFreq: "weekly",
+			})
+		}
+	}
+
+	blogOffset := start - len(h.staticPaths)
+	if blogOffset < 0 {
+		blogOffset = 0
+	}
+	blogs, err := h.repo.GetPublishedForSitemap(h.maxURLs-len(urls), blogOffset)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	for _, blog := range blogs {
+		entry := models.SitemapURL{
+			Loc:     utils.PostURL(blog.Slug),
+			LastMod: blog.UpdatedAt.UTC().Format(time.RFC3339),
+		}
+		if blog.FeaturedImage != nil && *blog.FeaturedImage != "" {
+			entry.Images = []models.SitemapImage{{Loc: *blog.FeaturedImage, Title: blog.Title}}
+		}
+		urls = append(urls, entry)
+	}
+
+	writeXML(c, "application/xml; charset=utf-8", models.SitemapURLSet{
+		ImageNS: "http://www.google.com/schemas/sitemap-image/1.1",
+		URLs:    urls,
+	})
+}
+
+// sitemapBaseURL is where the sitemap files themselves are reachable:
+// SITEMAP_BASE_URL, else the API's public URL, else the site, which then has
+// to proxy them. The sitemap protocol only accepts absolute URLs.
+func sitemapBaseURL() string {
+	if base := os.Getenv("SITEMAP_BASE_URL"); base != "" {
+		return strings.TrimRight(base, "/")
+	}
+	return feedBaseURL()
+}
+
+func splitList(value string) []string {
+	list := []string{}
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+	return list
+}
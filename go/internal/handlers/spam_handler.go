@@ -0,0 +1,102 @@
+package handlers
+
+import (
+	"blog-api/internal/models"
+	"blog-api/internal/services"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+type SpamHandler struct {
+	spamService *services.SpamService
+}
+
+func NewSpamHandler(spamService *services.SpamService) *SpamHandler {
+	return &SpamHandler{spamService: spamService}
+}
+
+func (h *SpamHandler) GetSettings(c *gin.Context) {
+	settings, err := h.spamService.Settings()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, settings)
+}
+
+func (h *SpamHandler) UpdateSettings(c *gin.Context) {
+	var req models.UpdateSpamSettingsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	current, err := h.spamService.Settings()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	settings := *current
+
+	if req.Enabled != nil {
+		settings.Enabled = *req.Enabled
+	}
+	if req.Threshold != nil {
+		if *req.Threshold <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "threshold must be greater than 0"})
+			return
+		}
+		settings.Threshold = *req.Threshold
+	}
+	if req.MaxLinks != nil {
+		if *req.MaxLinks < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "max_links must not be negative"})
+			return
+		}
+		settings.MaxLinks = *req.MaxLinks
+	}
+	if req.BlockedWords != nil {
+		settings.BlockedWords = normalizeList(*req.BlockedWords)
+	}
+	if req.BlockedDomains != nil {
+		settings.BlockedDomains = normalizeList(*req.BlockedDomains)
+	}
+	if req.MinSubmitSeconds != nil {
+		if *req.MinSubmitSeconds < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "min_submit_seconds must not be negative"})
+			return
+		}
+		settings.MinSubmitSeconds = *req.MinSubmitSeconds
+	}
+	if req.DuplicateWindowHours != nil {
+		if *req.DuplicateWindowHours < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "duplicate_window_hours must not be negative"})
+			return
+		}
+		settings.DuplicateWindowHours = *req.DuplicateWindowHours
+	}
+
+	if err := h.spamService.SaveSettings(&settings); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, settings)
+}
+
+func normalizeList(values []string) models.StringArray {
+	seen := make(map[string]bool, len(values))
+	list := models.StringArray{}
+	for _, value := range values {
+		value = strings.ToLower(strings.TrimSpace(value))
+		if value == "" || seen[value] {
+			continue
+		}
+		seen[value] = true
+		list = append(list, value)
+	}
+	return list
+}
@@ -0,0 +1,68 @@
+package handlers
+
+import (
+	"blog-api/internal/repository"
+	"blog-api/internal/services"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type StatsHandler struct {
+	repo            *repository.BlogRepository
+	markdownService *services.MarkdownService
+}
+
+func NewStatsHandler(markdownService *services.MarkdownService) *StatsHandler {
+	return &StatsHandler{
+		repo:            repository.NewBlogRepository(),
+		markdownService: markdownService,
+	}
+}
+
+func (h *StatsHandler) GetContentStats(c *gin.Context) {
+	monthly, err := h.repo.WordsPublishedByMonth()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	categories, err := h.repo.LengthByCategory()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	var totalWords int64
+	totalPosts := 0
+	for _, month := range monthly {
+		totalWords += month.Words
+		totalPosts += month.Posts
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"total_posts":        totalPosts,
+		"total_words":        totalWords,
+		"words_by_month":     monthly,
+		"length_by_category": categories,
+	})
+}
+
+// RecomputeStats refreshes the stored statistics of every post, e.g. after
+// the counting rules change or for posts created before they existed.
+func (h *StatsHandler) RecomputeStats(c *gin.Context) {
+	blogs, err := h.repo.GetAllForStats()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	for _, blog := range blogs {
+		if err := h.repo.SetStats(blog.ID, h.markdownService.Stats(blog.Content)); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{"updated": len(blogs)})
+}
@@ -0,0 +1,42 @@
+package handlers
+
+import (
+	"blog-api/internal/services"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type StorageHandler struct {
+	assets *services.AssetService
+}
+
+func NewStorageHandler(assets *services.AssetService) *StorageHandler {
+	return &StorageHandler{assets: assets}
+}
+
+// Reconcile reports objects in storage that nothing in the database owns.
+// With ?purge=true they are deleted as well.
+func (h *StorageHandler) Reconcile(c *gin.Context) {
+	purge, _ := strconv.ParseBool(c.Query("purge"))
+
+	minAge := time.Hour
+	if minAgeStr := c.Query("min_age"); minAgeStr != "" {
+		parsed, err := time.ParseDuration(minAgeStr)
+		if err != nil || parsed < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid min_age, expected a duration such as 30m or 24h"})
+			return
+		}
+		minAge = parsed
+	}
+
+	report, err := h.assets.Reconcile(c.Request.Context(), purge, minAge)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
@@ -0,0 +1,138 @@
+package handlers
+
+import (
+	"blog-api/internal/middleware"
+	"blog-api/internal/models"
+	"blog-api/internal/services"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"mime/multipart"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// parseUploadForm reads a multipart body up front so that one running past
+// the route's body limit gets a 413 before any of it is used; files beyond
+// the router's MaxMultipartMemory are spooled to temporary files rather than
+// held in memory. Other content types are left alone.
+func parseUploadForm(c *gin.Context) bool {
+	if !strings.HasPrefix(c.ContentType(), "multipart/") {
+		return true
+	}
+	if _, err := c.MultipartForm(); err != nil {
+		var maxErr *http.MaxBytesError
+		if errors.As(err, &maxErr) {
+			middleware.BodyTooLarge(c, maxErr.Limit)
+			return false
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid multipart form"})
+		return false
+	}
+	return true
+}
+
+// prepareImageUpload validates an uploaded image by its content and returns
+// it with metadata stripped. The image reads from the form file as it is
+// stored, so the caller closes it when done. It writes the error response
+// itself when the upload is refused.
+func prepareImageUpload(c *gin.Context, images *services.ImageService, file *multipart.FileHeader) (*services.PreparedImage, bool) {
+	if file.Size > images.MaxBytes() {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{
+			"error": fmt.Sprintf("image exceeds the %d MB limit", images.MaxBytes()>>20),
+			"code":  services.ImageErrTooLarge,
+		})
+		return nil, false
+	}
+
+	src, err := file.Open()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to open image file"})
+		return nil, false
+	}
+
+	prepared, err := images.PrepareFile(src, file.Size)
+	if err != nil {
+		writeImageError(c, err)
+		return nil, false
+	}
+	return prepared, true
+}
+
+// writeImageError maps image validation errors to 415, 413 or 400 with
+// their code; anything else is a 500.
+func writeImageError(c *gin.Context, err error) {
+	var invalid *services.ImageValidationError
+	if !errors.As(err, &invalid) {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	status := http.StatusBadRequest
+	switch invalid.Code {
+	case services.ImageErrUnsupportedType:
+		status = http.StatusUnsupportedMediaType
+	case services.ImageErrTooLarge:
+		status = http.StatusRequestEntityTooLarge
+	}
+	c.JSON(status, gin.H{"error": invalid.Message, "code": invalid.Code})
+}
+
+type uploadedImage struct {
+	URL         string
+	Size        int64
+	Variants    models.ImageVariants
+	Placeholder *models.ImagePlaceholder
+	Keys        []string
+}
+
+// uploadImage stores a prepared image at keyBase plus its extension, and its
+// responsive variants under keyBase/, all owned by the given owner. If any
+// part fails, whatever was already stored is released again.
+func uploadImage(ctx context.Context, assets *services.AssetService, images *services.ImageService, image *services.PreparedImage, keyBase, ownerType string, ownerID uuid.UUID) (*uploadedImage, error) {
+	encoded, err := images.Variants(image)
+	if err != nil {
+		return nil, err
+	}
+	placeholder, err := images.Placeholder(image)
+	if err != nil {
+		return nil, err
+	}
+
+	uploaded := &uploadedImage{Placeholder: placeholder}
+	key := keyBase + image.Ext
+	stored, err := assets.Upload(ctx, key, image.Open(), image.Size, image.MimeType, ownerType, ownerID)
+	if err != nil {
+		return nil, err
+	}
+	uploaded.URL = stored.URL
+	uploaded.Size = image.Size
+	uploaded.Keys = append(uploaded.Keys, key)
+
+	for _, variant := range encoded {
+		key := keyBase + "/" + variant.Name + variant.Ext
+		stored, err := assets.Upload(ctx, key, bytes.NewReader(variant.Data), int64(len(variant.Data)), variant.MimeType, ownerType, ownerID)
+		if err != nil {
+			releaseUpload(ctx, assets, uploaded)
+			return nil, err
+		}
+		uploaded.Keys = append(uploaded.Keys, key)
+		uploaded.Variants = append(uploaded.Variants, models.ImageVariant{
+			Name:     variant.Name,
+			Width:    variant.Width,
+			Height:   variant.Height,
+			MimeType: variant.MimeType,
+			Size:     int64(len(variant.Data)),
+			URL:      stored.URL,
+		})
+	}
+	return uploaded, nil
+}
+
+func releaseUpload(ctx context.Context, assets *services.AssetService, uploaded *uploadedImage) {
+	releaseKeys(ctx, assets, uploaded.Keys)
+}
@@ -0,0 +1,43 @@
+package middleware
+
+import (
+	"blog-api/internal/services"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const challengeIssuedAtKey = "pow_issued_at"
+
+func ProofOfWork(pow *services.ProofOfWorkService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		challenge := c.GetHeader("X-PoW-Challenge")
+		solution := c.GetHeader("X-PoW-Solution")
+
+		issuedAt, err := pow.Verify(challenge, solution, c.ClientIP())
+		if err != nil {
+			c.JSON(http.StatusForbidden, gin.H{
+				"error":      err.Error(),
+				"challenge":  "/api/v1/public/challenge",
+				"difficulty": pow.DifficultyFor(c.ClientIP()),
+			})
+			c.Abort()
+			return
+		}
+
+		c.Set(challengeIssuedAtKey, issuedAt)
+		c.Next()
+	}
+}
+
+// ChallengeIssuedAt returns when the proof of work challenge that let the
+// request through was issued.
+func ChallengeIssuedAt(c *gin.Context) (time.Time, bool) {
+	issuedAt, ok := c.Get(challengeIssuedAtKey)
+	if !ok {
+		return time.Time{}, false
+	}
+	t, ok := issuedAt.(time.Time)
+	return t, ok
+}
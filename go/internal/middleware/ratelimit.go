@@ -14,6 +14,7 @@ import (
 type rateLimiter struct {
 	limiter  *rate.Limiter
 	lastSeen time.Time
+	rejected int
 }
 
 type RateLimitStore struct {
@@ -52,6 +53,36 @@ func (store *RateLimitStore) getVisitor(ip string) *rateLimiter {
 	return visitor
 }
 
+// Pressure reports how close ip is to its limit, from 0 (idle) to 1 (out of
+// tokens), plus how many of its requests have been rejected recently.
+func (store *RateLimitStore) Pressure(ip string) (float64, int) {
+	store.mu.RLock()
+	defer store.mu.RUnlock()
+
+	visitor, exists := store.visitors[ip]
+	if !exists || store.burst == 0 {
+		return 0, 0
+	}
+
+	used := 1 - visitor.limiter.Tokens()/float64(store.burst)
+	if used < 0 {
+		used = 0
+	}
+	if used > 1 {
+		used = 1
+	}
+	return used, visitor.rejected
+}
+
+func (store *RateLimitStore) recordRejection(ip string) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	if visitor, exists := store.visitors[ip]; exists {
+		visitor.rejected++
+	}
+}
+
 func (store *RateLimitStore) cleanupVisitors() {
 	for {
 		time.Sleep(time.Minute)
@@ -94,15 +125,54 @@ func getRateLimitStore() *RateLimitStore {
 	return rateLimitStore
 }
 
+// RateLimitPressure exposes the global store's view of ip so other defences
+// can scale with how noisy a client is.
+func RateLimitPressure(ip string) (float64, int) {
+	return getRateLimitStore().Pressure(ip)
+}
+
 func RateLimit() gin.HandlerFunc {
+	return limitWith(getRateLimitStore, "Rate limit exceeded. Please try again later.")
+}
+
+var (
+	reactionRateLimitStore *RateLimitStore
+	reactionOnce           sync.Once
+)
+
+func getReactionRateLimitStore() *RateLimitStore {
+	reactionOnce.Do(func() {
+		perMinute := 10.0
+		if parsed, err := strconv.ParseFloat(os.Getenv("REACTION_RATE_PER_MINUTE"), 64); err == nil && parsed > 0 {
+			perMinute = parsed
+		}
+
+		burst := 5
+		if parsed, err := strconv.Atoi(os.Getenv("REACTION_RATE_BURST")); err == nil && parsed > 0 {
+			burst = parsed
+		}
+
+		reactionRateLimitStore = NewRateLimitStore(perMinute/60, burst)
+	})
+	return reactionRateLimitStore
+}
+
+// ReactionRateLimit is a much stricter per-IP limit for anonymous reactions,
+// applied on top of RateLimit().
+func ReactionRateLimit() gin.HandlerFunc {
+	return limitWith(getReactionRateLimitStore, "Too many reactions. Please slow down.")
+}
+
+func limitWith(getStore func() *RateLimitStore, message string) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		store := getRateLimitStore()
+		store := getStore()
 		ip := c.ClientIP()
 
 		visitor := store.getVisitor(ip)
 		if !visitor.limiter.Allow() {
+			store.recordRejection(ip)
 			c.JSON(http.StatusTooManyRequests, gin.H{
-				"error": "Rate limit exceeded. Please try again later.",
+				"error": message,
 			})
 			c.Abort()
 			return
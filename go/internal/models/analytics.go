@@ -0,0 +1,59 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// PageView holds one anonymized visitor per blog per day. VisitorHash is a
+// keyed hash of the day, IP and User-Agent, so raw IPs are never stored and
+// the same visitor hashes differently on different days. Rows only serve to
+// count unique visitors and are purged after a few days; the totals live on
+// in BlogDailyStat.
+type PageView struct {
+	BlogID       uuid.UUID `json:"blog_id" gorm:"type:uuid;primaryKey"`
+	Day          time.Time `json:"day" gorm:"type:date;primaryKey"`
+	VisitorHash  string    `json:"-" gorm:"type:varchar(64);primaryKey"`
+	ReferrerHost string    `json:"referrer_host" gorm:"type:varchar(255)"`
+	DeviceClass  string    `json:"device_class" gorm:"type:varchar(20)"`
+	Views        int       `json:"views" gorm:"not null;default:1"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+type BlogDailyStat struct {
+	BlogID         uuid.UUID `json:"blog_id" gorm:"type:uuid;primaryKey"`
+	Day            time.Time `json:"day" gorm:"type:date;primaryKey;index"`
+	Views          int       `json:"views" gorm:"not null;default:0"`
+	UniqueVisitors int       `json:"unique_visitors" gorm:"not null;default:0"`
+}
+
+type BlogReferrerStat struct {
+	BlogID       uuid.UUID `json:"blog_id" gorm:"type:uuid;primaryKey"`
+	Day          time.Time `json:"day" gorm:"type:date;primaryKey;index"`
+	ReferrerHost string    `json:"referrer_host" gorm:"type:varchar(255);primaryKey"`
+	Views        int       `json:"views" gorm:"not null;default:0"`
+}
+
+type RecordViewRequest struct {
+	Referrer string `json:"referrer"`
+}
+
+type TopPost struct {
+	BlogID         uuid.UUID `json:"blog_id"`
+	Title          string    `json:"title"`
+	Slug           string    `json:"slug"`
+	Views          int       `json:"views"`
+	UniqueVisitors int       `json:"unique_visitors"`
+}
+
+type DailyViews struct {
+	Day            string `json:"day"`
+	Views          int    `json:"views"`
+	UniqueVisitors int    `json:"unique_visitors"`
+}
+
+type TopReferrer struct {
+	ReferrerHost string `json:"referrer_host"`
+	Views        int    `json:"views"`
+}
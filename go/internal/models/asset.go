@@ -0,0 +1,55 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	AssetOwnerBlog  = "blog"
+	AssetOwnerMedia = "media"
+)
+
+// Asset records who owns an uploaded object so it can be removed from
+// storage together with its owner.
+type Asset struct {
+	Key       string     `json:"key" gorm:"type:varchar(512);primaryKey"`
+	URL       string     `json:"url" gorm:"type:varchar(1024);not null"`
+	Storage   string     `json:"storage" gorm:"type:varchar(20);not null"`
+	OwnerType string     `json:"owner_type" gorm:"type:varchar(20);index:idx_assets_owner"`
+	OwnerID   *uuid.UUID `json:"owner_id,omitempty" gorm:"type:uuid;index:idx_assets_owner"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+type OrphanAsset struct {
+	Key     string    `json:"key"`
+	URL     string    `json:"url"`
+	Size    int64     `json:"size"`
+	ModTime time.Time `json:"mod_time"`
+	Reason  string    `json:"reason"`
+}
+
+type ReconcileReport struct {
+	Storage    string         `json:"storage"`
+	Purge      bool           `json:"purge"`
+	Scanned    int            `json:"scanned"`
+	Referenced int            `json:"referenced"`
+	Adopted    []string       `json:"adopted"`
+	Orphans    []*OrphanAsset `json:"orphans"`
+	Missing    []string       `json:"missing"`
+	Purged     []string       `json:"purged"`
+	Failed     []string       `json:"failed"`
+}
+
+type PlaceholderFailure struct {
+	URL   string `json:"url"`
+	Error string `json:"error"`
+}
+
+type PlaceholderReport struct {
+	Blogs   int                   `json:"blogs"`
+	Media   int                   `json:"media"`
+	Skipped int                   `json:"skipped"`
+	Failed  []*PlaceholderFailure `json:"failed"`
+}
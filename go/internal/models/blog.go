@@ -35,18 +35,86 @@ func (a StringArray) GormDataType() string {
 }
 
 type Blog struct {
-	ID            uuid.UUID   `json:"id" gorm:"type:uuid;primary_key"`
-	Title         string      `json:"title" gorm:"type:varchar(255);not null"`
-	Slug          string      `json:"slug" gorm:"type:varchar(255);uniqueIndex;not null"`
-	Content       string      `json:"content" gorm:"type:text;not null"`
-	Excerpt       *string     `json:"excerpt,omitempty" gorm:"type:text"`
-	Category      string      `json:"category" gorm:"type:varchar(100)"`
-	Tags          StringArray `json:"tags" gorm:"type:jsonb"`
-	Status        string      `json:"status" gorm:"type:varchar(20);default:'draft'"`
-	FeaturedImage *string     `json:"featured_image,omitempty" gorm:"type:varchar(255)"`
-	PublishedAt   *time.Time  `json:"published_at,omitempty" gorm:"type:timestamp"`
-	CreatedAt     time.Time   `json:"created_at"`
-	UpdatedAt     time.Time   `json:"updated_at"`
+	ID                    uuid.UUID     `json:"id" gorm:"type:uuid;primary_key"`
+	Title                 string        `json:"title" gorm:"type:varchar(255);not null"`
+	Slug                  string        `json:"slug" gorm:"type:varchar(255);uniqueIndex;not null"`
+	Content               string        `json:"content" gorm:"type:text;not null"`
+	Excerpt               *string       `json:"excerpt,omitempty" gorm:"type:text"`
+	Category              string        `json:"category" gorm:"type:varchar(100)"`
+	Tags                  StringArray   `json:"tags" gorm:"type:jsonb"`
+	Status                string        `json:"status" gorm:"type:varchar(20);default:'draft'"`
+	FeaturedImage         *string       `json:"featured_image,omitempty" gorm:"type:varchar(255)"`
+	FeaturedImageVariants ImageVariants `json:"-" gorm:"type:jsonb"`
+	FeaturedImageMeta
+	ContentStats
+	SEOFields
+	PublishedAt *time.Time `json:"published_at,omitempty" gorm:"type:timestamp"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+
+	ContentHTML string           `json:"content_html,omitempty" gorm:"-"`
+	TOC         []TOCEntry       `json:"toc,omitempty" gorm:"-"`
+	Reactions   map[string]int64 `json:"reactions,omitempty" gorm:"-"`
+
+	FeaturedImageResponsive *ResponsiveImage `json:"featured_image_responsive,omitempty" gorm:"-"`
+
+	Sanitization []SanitizeFinding `json:"sanitization,omitempty" gorm:"-"`
+}
+
+type SanitizeFinding struct {
+	Field  string `json:"field"`
+	Type   string `json:"type"`
+	Detail string `json:"detail"`
+}
+
+type ContentStats struct {
+	WordCount          int `json:"word_count" gorm:"not null;default:0"`
+	ReadingTimeMinutes int `json:"reading_time_minutes" gorm:"not null;default:0"`
+	HeadingCount       int `json:"heading_count" gorm:"not null;default:0"`
+	CodeBlockCount     int `json:"code_block_count" gorm:"not null;default:0"`
+	ImageCount         int `json:"image_count" gorm:"not null;default:0"`
+}
+
+func (s ContentStats) Updates() map[string]interface{} {
+	return map[string]interface{}{
+		"word_count":           s.WordCount,
+		"reading_time_minutes": s.ReadingTimeMinutes,
+		"heading_count":        s.HeadingCount,
+		"code_block_count":     s.CodeBlockCount,
+		"image_count":          s.ImageCount,
+	}
+}
+
+type SEOFields struct {
+	MetaDescription *string `json:"meta_description,omitempty" gorm:"type:varchar(320)"`
+	CanonicalURL    *string `json:"canonical_url,omitempty" gorm:"type:varchar(2048)"`
+	OGImage         *string `json:"og_image,omitempty" gorm:"type:varchar(2048)"`
+	NoIndex         bool    `json:"noindex" gorm:"not null;default:false"`
+	TwitterCard     string  `json:"twitter_card,omitempty" gorm:"type:varchar(32)"`
+}
+
+type MonthlyWordStat struct {
+	Month string `json:"month"`
+	Posts int    `json:"posts"`
+	Words int64  `json:"words"`
+}
+
+type CategoryLengthStat struct {
+	Category                  string  `json:"category"`
+	Posts                     int     `json:"posts"`
+	AverageWords              float64 `json:"average_words"`
+	AverageReadingTimeMinutes float64 `json:"average_reading_time_minutes"`
+}
+
+type TOCEntry struct {
+	Level int    `json:"level"`
+	Text  string `json:"text"`
+	ID    string `json:"id"`
+}
+
+func (b *Blog) AfterFind(tx *gorm.DB) error {
+	b.FeaturedImageResponsive = b.FeaturedImageVariants.Responsive()
+	return nil
 }
 
 func (b *Blog) BeforeCreate(tx *gorm.DB) error {
@@ -69,4 +137,10 @@ type UpdateBlogRequest struct {
 	Tags          *[]string `json:"tags"`
 	Status        *string   `json:"status"`
 	FeaturedImage *string   `json:"featured_image"`
+
+	MetaDescription *string `json:"meta_description"`
+	CanonicalURL    *string `json:"canonical_url"`
+	OGImage         *string `json:"og_image"`
+	NoIndex         *bool   `json:"noindex"`
+	TwitterCard     *string `json:"twitter_card"`
 }
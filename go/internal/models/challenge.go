@@ -0,0 +1,10 @@
+package models
+
+import "time"
+
+// SpentChallenge remembers a redeemed proof-of-work challenge until it
+// expires, so it cannot be replayed against any instance.
+type SpentChallenge struct {
+	Nonce     string    `json:"nonce" gorm:"type:varchar(64);primaryKey"`
+	ExpiresAt time.Time `json:"expires_at" gorm:"not null;index"`
+}
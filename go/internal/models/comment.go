@@ -0,0 +1,75 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const (
+	CommentStatusPending  = "pending"
+	CommentStatusApproved = "approved"
+	CommentStatusSpam     = "spam"
+	CommentStatusDeleted  = "deleted"
+)
+
+func IsValidCommentStatus(status string) bool {
+	switch status {
+	case CommentStatusPending, CommentStatusApproved, CommentStatusSpam, CommentStatusDeleted:
+		return true
+	}
+	return false
+}
+
+type Comment struct {
+	ID          uuid.UUID   `json:"id" gorm:"type:uuid;primary_key"`
+	BlogID      uuid.UUID   `json:"blog_id" gorm:"type:uuid;not null;index"`
+	ParentID    *uuid.UUID  `json:"parent_id,omitempty" gorm:"type:uuid;index"`
+	Depth       int         `json:"depth" gorm:"not null;default:0"`
+	AuthorName  string      `json:"author_name" gorm:"type:varchar(100);not null"`
+	AuthorEmail string      `json:"author_email" gorm:"type:varchar(255);not null"`
+	Body        string      `json:"body" gorm:"type:text;not null"`
+	BodyHTML    string      `json:"body_html" gorm:"type:text;not null"`
+	Status      string      `json:"status" gorm:"type:varchar(20);default:'pending';index"`
+	VisitorHash string      `json:"-" gorm:"type:varchar(64);index"`
+	ContentHash string      `json:"-" gorm:"type:varchar(64);index"`
+	SpamScore   float64     `json:"spam_score" gorm:"not null;default:0"`
+	SpamReasons StringArray `json:"spam_reasons" gorm:"type:jsonb"`
+	CreatedAt   time.Time   `json:"created_at"`
+	UpdatedAt   time.Time   `json:"updated_at"`
+}
+
+func (c *Comment) BeforeCreate(tx *gorm.DB) error {
+	if c.ID == uuid.Nil {
+		c.ID = uuid.New()
+	}
+	if c.Status == "" {
+		c.Status = CommentStatusPending
+	}
+	return nil
+}
+
+type CreateCommentRequest struct {
+	ParentID    *uuid.UUID `json:"parent_id"`
+	AuthorName  string     `json:"author_name" binding:"required,max=100"`
+	AuthorEmail string     `json:"author_email" binding:"required,email,max=255"`
+	Body        string     `json:"body" binding:"required,max=5000"`
+	Website     string     `json:"website"`
+}
+
+type ModerateCommentsRequest struct {
+	IDs    []uuid.UUID `json:"ids" binding:"required,min=1,max=500"`
+	Status string      `json:"status" binding:"required"`
+}
+
+// PublicComment is the shape served to readers: no email, no status, and
+// replies nested under their parent.
+type PublicComment struct {
+	ID         uuid.UUID        `json:"id"`
+	ParentID   *uuid.UUID       `json:"parent_id,omitempty"`
+	AuthorName string           `json:"author_name"`
+	BodyHTML   string           `json:"body_html"`
+	CreatedAt  time.Time        `json:"created_at"`
+	Replies    []*PublicComment `json:"replies"`
+}
@@ -0,0 +1,145 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ImageVariant is one resized rendition of an uploaded image.
+type ImageVariant struct {
+	Name     string `json:"name"`
+	Width    int    `json:"width"`
+	Height   int    `json:"height"`
+	MimeType string `json:"mime_type"`
+	Size     int64  `json:"size"`
+	URL      string `json:"url"`
+}
+
+type ImageVariants []ImageVariant
+
+func (v *ImageVariants) Scan(value interface{}) error {
+	if value == nil {
+		*v = nil
+		return nil
+	}
+	bytes, ok := value.([]byte)
+	if !ok {
+		return nil
+	}
+	return json.Unmarshal(bytes, v)
+}
+
+func (v ImageVariants) Value() (driver.Value, error) {
+	if len(v) == 0 {
+		return nil, nil
+	}
+	return json.Marshal(v)
+}
+
+func (v ImageVariants) GormDataType() string {
+	return "jsonb"
+}
+
+// ResponsiveImage maps directly onto <picture>/<img srcset> markup or the
+// Next.js Image loader: Sources lists modern formats to offer first, SrcSet
+// the widely supported fallback.
+type ResponsiveImage struct {
+	Src     string             `json:"src"`
+	SrcSet  string             `json:"srcset"`
+	Sizes   string             `json:"sizes"`
+	Width   int                `json:"width"`
+	Height  int                `json:"height"`
+	Sources []ResponsiveSource `json:"sources"`
+}
+
+type ResponsiveSource struct {
+	Type   string `json:"type"`
+	SrcSet string `json:"srcset"`
+}
+
+func (v ImageVariants) Responsive() *ResponsiveImage {
+	var fallback ImageVariants
+	modern := make(map[string]ImageVariants)
+	for _, variant := range v {
+		if variant.MimeType == "image/webp" || variant.MimeType == "image/avif" {
+			modern[variant.MimeType] = append(modern[variant.MimeType], variant)
+		} else {
+			fallback = append(fallback, variant)
+		}
+	}
+	if len(fallback) == 0 {
+		return nil
+	}
+
+	largest := fallback.sorted()[len(fallback)-1]
+	image := &ResponsiveImage{
+		Src:     largest.URL,
+		SrcSet:  fallback.srcSet(),
+		Sizes:   "(max-width: " + strconv.Itoa(largest.Width) + "px) 100vw, " + strconv.Itoa(largest.Width) + "px",
+		Width:   largest.Width,
+		Height:  largest.Height,
+		Sources: []ResponsiveSource{},
+	}
+	for _, mimeType := range []string{"image/avif", "image/webp"} {
+		if variants, ok := modern[mimeType]; ok {
+			image.Sources = append(image.Sources, ResponsiveSource{Type: mimeType, SrcSet: variants.srcSet()})
+		}
+	}
+	return image
+}
+
+func (v ImageVariants) sorted() ImageVariants {
+	sorted := append(ImageVariants(nil), v...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Width < sorted[j].Width })
+	return sorted
+}
+
+func (v ImageVariants) srcSet() string {
+	parts := make([]string, 0, len(v))
+	for _, variant := range v.sorted() {
+		parts = append(parts, variant.URL+" "+strconv.Itoa(variant.Width)+"w")
+	}
+	return strings.Join(parts, ", ")
+}
+
+// ImagePlaceholder is what a page needs to reserve space for an image and
+// paint a blurred preview before it loads.
+type ImagePlaceholder struct {
+	Blurhash      string `json:"blurhash"`
+	DominantColor string `json:"dominant_color"`
+	Width         int    `json:"width"`
+	Height        int    `json:"height"`
+}
+
+// FeaturedImageMeta holds the placeholder of a blog's featured image. The
+// fields are nil when the image is external or has not been analysed yet.
+type FeaturedImageMeta struct {
+	FeaturedImageBlurhash      *string `json:"featured_image_blurhash,omitempty" gorm:"type:varchar(64)"`
+	FeaturedImageDominantColor *string `json:"featured_image_dominant_color,omitempty" gorm:"type:varchar(7)"`
+	FeaturedImageWidth         *int    `json:"featured_image_width,omitempty"`
+	FeaturedImageHeight        *int    `json:"featured_image_height,omitempty"`
+}
+
+func (p *ImagePlaceholder) FeaturedImageMeta() FeaturedImageMeta {
+	if p == nil {
+		return FeaturedImageMeta{}
+	}
+	return FeaturedImageMeta{
+		FeaturedImageBlurhash:      &p.Blurhash,
+		FeaturedImageDominantColor: &p.DominantColor,
+		FeaturedImageWidth:         &p.Width,
+		FeaturedImageHeight:        &p.Height,
+	}
+}
+
+func (m FeaturedImageMeta) Updates() map[string]interface{} {
+	return map[string]interface{}{
+		"featured_image_blurhash":       m.FeaturedImageBlurhash,
+		"featured_image_dominant_color": m.FeaturedImageDominantColor,
+		"featured_image_width":          m.FeaturedImageWidth,
+		"featured_image_height":         m.FeaturedImageHeight,
+	}
+}
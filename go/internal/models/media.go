@@ -0,0 +1,49 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Media is an uploaded file in the media library. PublicID is the key the
+// file is stored under in the configured storage backend.
+type Media struct {
+	ID            uuid.UUID     `json:"id" gorm:"type:uuid;primary_key"`
+	URL           string        `json:"url" gorm:"type:varchar(1024);not null"`
+	PublicID      string        `json:"public_id" gorm:"type:varchar(512);uniqueIndex;not null"`
+	Storage       string        `json:"storage" gorm:"type:varchar(20);not null"`
+	Filename      string        `json:"filename" gorm:"type:varchar(255)"`
+	MimeType      string        `json:"mime_type" gorm:"type:varchar(100);index"`
+	Size          int64         `json:"size" gorm:"not null;default:0"`
+	Width         int           `json:"width" gorm:"not null;default:0"`
+	Height        int           `json:"height" gorm:"not null;default:0"`
+	AltText       string        `json:"alt_text" gorm:"type:text"`
+	UploadedBy    string        `json:"uploaded_by" gorm:"type:varchar(255)"`
+	Variants      ImageVariants `json:"variants" gorm:"type:jsonb"`
+	Blurhash      string        `json:"blurhash,omitempty" gorm:"type:varchar(64)"`
+	DominantColor string        `json:"dominant_color,omitempty" gorm:"type:varchar(7)"`
+	CreatedAt     time.Time     `json:"created_at" gorm:"index"`
+	UpdatedAt     time.Time     `json:"updated_at"`
+
+	Responsive *ResponsiveImage `json:"responsive,omitempty" gorm:"-"`
+}
+
+func (m *Media) AfterFind(tx *gorm.DB) error {
+	m.Responsive = m.Variants.Responsive()
+	return nil
+}
+
+// Placeholder is nil for entries uploaded before placeholders were computed
+// and not backfilled since.
+func (m *Media) Placeholder() *ImagePlaceholder {
+	if m.Blurhash == "" {
+		return nil
+	}
+	return &ImagePlaceholder{Blurhash: m.Blurhash, DominantColor: m.DominantColor, Width: m.Width, Height: m.Height}
+}
+
+type UpdateMediaRequest struct {
+	AltText *string `json:"alt_text"`
+}
@@ -0,0 +1,46 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var ReactionTypes = map[string]string{
+	"clap":     "👏",
+	"heart":    "❤️",
+	"fire":     "🔥",
+	"rocket":   "🚀",
+	"thinking": "🤔",
+}
+
+func IsValidReactionType(reactionType string) bool {
+	_, ok := ReactionTypes[reactionType]
+	return ok
+}
+
+type Reaction struct {
+	BlogID      uuid.UUID `json:"blog_id" gorm:"type:uuid;primaryKey"`
+	VisitorHash string    `json:"-" gorm:"type:varchar(64);primaryKey"`
+	Type        string    `json:"type" gorm:"type:varchar(20);primaryKey"`
+	CreatedAt   time.Time `json:"created_at" gorm:"index"`
+}
+
+// ReactionEvent logs each reaction a visitor adds. Unlike reactions it is
+// not deleted when the reaction is taken back, so the hourly limit counts
+// every add.
+type ReactionEvent struct {
+	ID          uint      `json:"id" gorm:"primaryKey"`
+	VisitorHash string    `json:"-" gorm:"type:varchar(64);not null;index"`
+	CreatedAt   time.Time `json:"created_at" gorm:"index"`
+}
+
+type ReactionRequest struct {
+	Type string `json:"type" binding:"required"`
+}
+
+type ReactionSummary struct {
+	Counts  map[string]int64 `json:"counts"`
+	Total   int64            `json:"total"`
+	Reacted []string         `json:"reacted"`
+}
@@ -0,0 +1,57 @@
+package models
+
+// PageMetadata mirrors the subset of Next.js' Metadata object that
+// generateMetadata needs, so the frontend can return it as-is.
+type PageMetadata struct {
+	Title       string            `json:"title"`
+	Description string            `json:"description"`
+	Alternates  MetadataAlternate `json:"alternates"`
+	Robots      MetadataRobots    `json:"robots"`
+	OpenGraph   OpenGraph         `json:"openGraph"`
+	Twitter     TwitterCard       `json:"twitter"`
+	MetaTags    []MetaTag         `json:"meta_tags"`
+}
+
+type MetadataAlternate struct {
+	Canonical string `json:"canonical"`
+}
+
+type MetadataRobots struct {
+	Index  bool `json:"index"`
+	Follow bool `json:"follow"`
+}
+
+type OpenGraph struct {
+	Title         string           `json:"title"`
+	Description   string           `json:"description"`
+	URL           string           `json:"url"`
+	SiteName      string           `json:"siteName,omitempty"`
+	Type          string           `json:"type"`
+	PublishedTime string           `json:"publishedTime,omitempty"`
+	ModifiedTime  string           `json:"modifiedTime"`
+	Section       string           `json:"section,omitempty"`
+	Tags          []string         `json:"tags,omitempty"`
+	Authors       []string         `json:"authors,omitempty"`
+	Images        []OpenGraphImage `json:"images"`
+}
+
+type OpenGraphImage struct {
+	URL    string `json:"url"`
+	Width  int    `json:"width,omitempty"`
+	Height int    `json:"height,omitempty"`
+	Alt    string `json:"alt"`
+}
+
+type TwitterCard struct {
+	Card        string   `json:"card"`
+	Title       string   `json:"title"`
+	Description string   `json:"description"`
+	Images      []string `json:"images"`
+}
+
+// MetaTag is a flat <meta> representation for consumers other than Next.js.
+type MetaTag struct {
+	Name     string `json:"name,omitempty"`
+	Property string `json:"property,omitempty"`
+	Content  string `json:"content"`
+}
@@ -0,0 +1,19 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// SlugHistory records a slug a blog used to have. Old slugs stay reserved
+// for that blog so previously shared links keep resolving.
+type SlugHistory struct {
+	Slug      string    `json:"slug" gorm:"type:varchar(255);primaryKey"`
+	BlogID    uuid.UUID `json:"blog_id" gorm:"type:uuid;not null;index"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func (SlugHistory) TableName() string {
+	return "slug_history"
+}
@@ -0,0 +1,40 @@
+package models
+
+import "time"
+
+// SpamSettings is a single-row table so moderation rules can be tuned at
+// runtime through the admin API instead of via environment variables.
+type SpamSettings struct {
+	ID                   uint        `json:"-" gorm:"primaryKey"`
+	Enabled              bool        `json:"enabled" gorm:"not null"`
+	Threshold            float64     `json:"threshold" gorm:"not null"`
+	MaxLinks             int         `json:"max_links" gorm:"not null"`
+	BlockedWords         StringArray `json:"blocked_words" gorm:"type:jsonb"`
+	BlockedDomains       StringArray `json:"blocked_domains" gorm:"type:jsonb"`
+	MinSubmitSeconds     int         `json:"min_submit_seconds" gorm:"not null"`
+	DuplicateWindowHours int         `json:"duplicate_window_hours" gorm:"not null"`
+	UpdatedAt            time.Time   `json:"updated_at"`
+}
+
+func DefaultSpamSettings() *SpamSettings {
+	return &SpamSettings{
+		ID:                   1,
+		Enabled:              true,
+		Threshold:            5,
+		MaxLinks:             2,
+		BlockedWords:         StringArray{},
+		BlockedDomains:       StringArray{},
+		MinSubmitSeconds:     3,
+		DuplicateWindowHours: 24,
+	}
+}
+
+type UpdateSpamSettingsRequest struct {
+	Enabled              *bool     `json:"enabled"`
+	Threshold            *float64  `json:"threshold"`
+	MaxLinks             *int      `json:"max_links"`
+	BlockedWords         *[]string `json:"blocked_words"`
+	BlockedDomains       *[]string `json:"blocked_domains"`
+	MinSubmitSeconds     *int      `json:"min_submit_seconds"`
+	DuplicateWindowHours *int      `json:"duplicate_window_hours"`
+}
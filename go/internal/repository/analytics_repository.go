@@ -0,0 +1,131 @@
+package repository
+
+import (
+	"blog-api/internal/database"
+	"blog-api/internal/models"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type AnalyticsRepository struct{}
+
+func NewAnalyticsRepository() *AnalyticsRepository {
+	return &AnalyticsRepository{}
+}
+
+func (r *AnalyticsRepository) RecordView(view *models.PageView) error {
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		result := tx.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "blog_id"}, {Name: "day"}, {Name: "visitor_hash"}},
+			DoUpdates: clause.Assignments(map[string]interface{}{"views": gorm.Expr("page_views.views + 1")}),
+		}).Create(view)
+		if result.Error != nil {
+			return result.Error
+		}
+
+		// A fresh visitor row comes back with views = 1; a repeat visit has
+		// already been bumped past that by the upsert above.
+		var views int
+		if err := tx.Model(&models.PageView{}).
+			Select("views").
+			Where("blog_id = ? AND day = ? AND visitor_hash = ?", view.BlogID, view.Day, view.VisitorHash).
+			Scan(&views).Error; err != nil {
+			return err
+		}
+		unique := 0
+		if views == 1 {
+			unique = 1
+		}
+
+		if err := tx.Clauses(clause.OnConflict{
+			Columns: []clause.Column{{Name: "blog_id"}, {Name: "day"}},
+			DoUpdates: clause.Assignments(map[string]interface{}{
+				"views":           gorm.Expr("blog_daily_stats.views + 1"),
+				"unique_visitors": gorm.Expr("blog_daily_stats.unique_visitors + ?", unique),
+			}),
+		}).Create(&models.BlogDailyStat{
+			BlogID:         view.BlogID,
+			Day:            view.Day,
+			Views:          1,
+			UniqueVisitors: unique,
+		}).Error; err != nil {
+			return err
+		}
+
+		if view.ReferrerHost == "" {
+			return nil
+		}
+		return tx.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "blog_id"}, {Name: "day"}, {Name: "referrer_host"}},
+			DoUpdates: clause.Assignments(map[string]interface{}{"views": gorm.Expr("blog_referrer_stats.views + 1")}),
+		}).Create(&models.BlogReferrerStat{
+			BlogID:       view.BlogID,
+			Day:          view.Day,
+			ReferrerHost: view.ReferrerHost,
+			Views:        1,
+		}).Error
+	})
+	if err != nil {
+		return fmt.Errorf("failed to record page view: %w", err)
+	}
+	return nil
+}
+
+// PurgeVisitors deletes per-visitor rows of days before the given one.
+func (r *AnalyticsRepository) PurgeVisitors(before time.Time) (int64, error) {
+	result := database.DB.Where("day < ?", before).Delete(&models.PageView{})
+	if result.Error != nil {
+		return 0, fmt.Errorf("failed to purge page views: %w", result.Error)
+	}
+	return result.RowsAffected, nil
+}
+
+func (r *AnalyticsRepository) TopPosts(from, to time.Time, limit int) ([]*models.TopPost, error) {
+	var posts []*models.TopPost
+	if err := database.DB.
+		Table("blog_daily_stats AS s").
+		Select("s.blog_id, b.title, b.slug, SUM(s.views) AS views, SUM(s.unique_visitors) AS unique_visitors").
+		Joins("JOIN blogs b ON b.id = s.blog_id").
+		Where("s.day BETWEEN ? AND ?", from, to).
+		Group("s.blog_id, b.title, b.slug").
+		Order("views DESC").
+		Limit(limit).
+		Scan(&posts).Error; err != nil {
+		return nil, fmt.Errorf("failed to get top posts: %w", err)
+	}
+	return posts, nil
+}
+
+func (r *AnalyticsRepository) DailyStats(blogID uuid.UUID, from, to time.Time) ([]*models.BlogDailyStat, error) {
+	var stats []*models.BlogDailyStat
+	if err := database.DB.
+		Where("blog_id = ? AND day BETWEEN ? AND ?", blogID, from, to).
+		Order("day ASC").
+		Find(&stats).Error; err != nil {
+		return nil, fmt.Errorf("failed to get daily stats: %w", err)
+	}
+	return stats, nil
+}
+
+func (r *AnalyticsRepository) TopReferrers(blogID *uuid.UUID, from, to time.Time, limit int) ([]*models.TopReferrer, error) {
+	var referrers []*models.TopReferrer
+	query := database.DB.
+		Model(&models.BlogReferrerStat{}).
+		Select("referrer_host, SUM(views) AS views").
+		Where("day BETWEEN ? AND ?", from, to)
+	if blogID != nil {
+		query = query.Where("blog_id = ?", *blogID)
+	}
+	if err := query.
+		Group("referrer_host").
+		Order("views DESC").
+		Limit(limit).
+		Scan(&referrers).Error; err != nil {
+		return nil, fmt.Errorf("failed to get top referrers: %w", err)
+	}
+	return referrers, nil
+}
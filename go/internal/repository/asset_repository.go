@@ -0,0 +1,76 @@
+package repository
+
+import (
+	"blog-api/internal/database"
+	"blog-api/internal/models"
+	"fmt"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type AssetRepository struct{}
+
+func NewAssetRepository() *AssetRepository {
+	return &AssetRepository{}
+}
+
+func (r *AssetRepository) Save(asset *models.Asset) error {
+	if err := database.DB.Clauses(clause.OnConflict{UpdateAll: true}).Create(asset).Error; err != nil {
+		return fmt.Errorf("failed to save asset: %w", err)
+	}
+	return nil
+}
+
+func (r *AssetRepository) GetByKey(key string) (*models.Asset, error) {
+	var asset models.Asset
+	if err := database.DB.Where("key = ?", key).First(&asset).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, fmt.Errorf("asset not found")
+		}
+		return nil, fmt.Errorf("failed to get asset: %w", err)
+	}
+	return &asset, nil
+}
+
+func (r *AssetRepository) GetByOwner(ownerType string, ownerID uuid.UUID) ([]*models.Asset, error) {
+	var assets []*models.Asset
+	if err := database.DB.
+		Where("owner_type = ? AND owner_id = ?", ownerType, ownerID).
+		Find(&assets).Error; err != nil {
+		return nil, fmt.Errorf("failed to get assets: %w", err)
+	}
+	return assets, nil
+}
+
+func (r *AssetRepository) GetByStorage(storage string) ([]*models.Asset, error) {
+	var assets []*models.Asset
+	if err := database.DB.Where("storage = ?", storage).Find(&assets).Error; err != nil {
+		return nil, fmt.Errorf("failed to get assets: %w", err)
+	}
+	return assets, nil
+}
+
+// GetDangling returns assets whose owning blog or media row no longer
+// exists.
+func (r *AssetRepository) GetDangling(storage string) ([]*models.Asset, error) {
+	var assets []*models.Asset
+	if err := database.DB.
+		Where("storage = ?", storage).
+		Where("(owner_type = ? AND NOT EXISTS (SELECT 1 FROM blogs WHERE blogs.id = assets.owner_id)) OR "+
+			"(owner_type = ? AND NOT EXISTS (SELECT 1 FROM media WHERE media.id = assets.owner_id)) OR "+
+			"owner_id IS NULL",
+			models.AssetOwnerBlog, models.AssetOwnerMedia).
+		Find(&assets).Error; err != nil {
+		return nil, fmt.Errorf("failed to get dangling assets: %w", err)
+	}
+	return assets, nil
+}
+
+func (r *AssetRepository) Delete(key string) error {
+	if err := database.DB.Where("key = ?", key).Delete(&models.Asset{}).Error; err != nil {
+		return fmt.Errorf("failed to delete asset: %w", err)
+	}
+	return nil
+}
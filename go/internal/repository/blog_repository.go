@@ -3,29 +3,130 @@ package repository
 import (
 	"blog-api/internal/database"
 	"blog-api/internal/models"
+	"blog-api/internal/utils"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"strconv"
+	"time"
 
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgconn"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
+var (
+	ErrSlugTaken    = errors.New("slug is already in use")
+	errBlogNotFound = errors.New("blog not found")
+)
+
+const blogColumns = "id, title, slug, content, excerpt, category, tags, status, featured_image, featured_image_variants, " +
+	"featured_image_blurhash, featured_image_dominant_color, featured_image_width, featured_image_height, " +
+	"word_count, reading_time_minutes, heading_count, code_block_count, image_count, " +
+	"meta_description, canonical_url, og_image, no_index, twitter_card, " +
+	"published_at, created_at, updated_at"
+
 type BlogRepository struct{}
 
 func NewBlogRepository() *BlogRepository {
 	return &BlogRepository{}
 }
 
+// Create stores the blog under the first free variant of blog.Slug: the
+// slug itself, then slug-2, slug-3 and so on. Losing a race for a candidate
+// trips the unique index, in which case the next free one is tried.
 func (r *BlogRepository) Create(blog *models.Blog) error {
-	if err := database.DB.Create(blog).Error; err != nil {
-		return fmt.Errorf("failed to create blog: %w", err)
+	base := blog.Slug
+	var err error
+	for attempt := 0; attempt < 5; attempt++ {
+		if blog.Slug, err = r.nextFreeSlug(base); err != nil {
+			return fmt.Errorf("failed to create blog: %w", err)
+		}
+		if err = database.DB.Create(blog).Error; err == nil {
+			return nil
+		}
+		if !isUniqueViolation(err) {
+			break
+		}
+	}
+	return fmt.Errorf("failed to create blog: %w", err)
+}
+
+func (r *BlogRepository) nextFreeSlug(base string) (string, error) {
+	var taken []string
+	pattern := base + "-%"
+	if err := database.DB.Raw(
+		"SELECT slug FROM blogs WHERE slug = ? OR slug LIKE ? UNION SELECT slug FROM slug_history WHERE slug = ? OR slug LIKE ?",
+		base, pattern, base, pattern,
+	).Scan(&taken).Error; err != nil {
+		return "", err
+	}
+
+	used := make(map[string]bool, len(taken))
+	for _, slug := range taken {
+		used[slug] = true
+	}
+	if !used[base] && !utils.IsReservedSlug(base) {
+		return base, nil
+	}
+	for n := 2; ; n++ {
+		candidate := base + "-" + strconv.Itoa(n)
+		if !used[candidate] {
+			return candidate, nil
+		}
+	}
+}
+
+func isUniqueViolation(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == "23505"
+}
+
+// slugAvailable reports ErrSlugTaken when the slug belongs to another blog,
+// either as its current slug or as one it used to have.
+func slugAvailable(tx *gorm.DB, slug string, blogID uuid.UUID) error {
+	var count int64
+	if err := tx.Model(&models.Blog{}).
+		Where("slug = ? AND id <> ?", slug, blogID).
+		Count(&count).Error; err != nil {
+		return err
+	}
+	if count > 0 {
+		return ErrSlugTaken
+	}
+
+	if err := tx.Model(&models.SlugHistory{}).
+		Where("slug = ? AND blog_id <> ?", slug, blogID).
+		Count(&count).Error; err != nil {
+		return err
+	}
+	if count > 0 {
+		return ErrSlugTaken
 	}
 	return nil
 }
 
+func (r *BlogRepository) ResolveOldSlug(slug string) (string, error) {
+	var current string
+	if err := database.DB.
+		Table("slug_history AS h").
+		Select("b.slug").
+		Joins("JOIN blogs b ON b.id = h.blog_id").
+		Where("h.slug = ?", slug).
+		Scan(&current).Error; err != nil {
+		return "", fmt.Errorf("failed to resolve slug: %w", err)
+	}
+	if current == "" {
+		return "", fmt.Errorf("blog not found")
+	}
+	return current, nil
+}
+
 func (r *BlogRepository) GetByID(id uuid.UUID) (*models.Blog, error) {
 	var blog models.Blog
 	if err := database.DB.
-		Select("id, title, slug, content, excerpt, category, tags, status, featured_image, published_at, created_at, updated_at").
+		Select(blogColumns).
 		Where("id = ?", id).
 		First(&blog).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
@@ -39,7 +140,7 @@ func (r *BlogRepository) GetByID(id uuid.UUID) (*models.Blog, error) {
 func (r *BlogRepository) GetBySlug(slug string) (*models.Blog, error) {
 	var blog models.Blog
 	if err := database.DB.
-		Select("id, title, slug, content, excerpt, category, tags, status, featured_image, published_at, created_at, updated_at").
+		Select(blogColumns).
 		Where("slug = ?", slug).
 		First(&blog).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
@@ -50,10 +151,24 @@ func (r *BlogRepository) GetBySlug(slug string) (*models.Blog, error) {
 	return &blog, nil
 }
 
+// GetByAnySlug is GetBySlug that also accepts a slug the post used to have.
+// It is for endpoints that act on a post rather than link to it.
+func (r *BlogRepository) GetByAnySlug(slug string) (*models.Blog, error) {
+	blog, err := r.GetBySlug(slug)
+	if err == nil {
+		return blog, nil
+	}
+	current, resolveErr := r.ResolveOldSlug(slug)
+	if resolveErr != nil {
+		return nil, err
+	}
+	return r.GetBySlug(current)
+}
+
 func (r *BlogRepository) GetAll(limit, offset int) ([]*models.Blog, error) {
 	var blogs []*models.Blog
 	if err := database.DB.
-		Select("id, title, slug, content, excerpt, category, tags, status, featured_image, published_at, created_at, updated_at").
+		Select(blogColumns).
 		Order("created_at DESC").
 		Limit(limit).
 		Offset(offset).
@@ -68,27 +183,231 @@ func (r *BlogRepository) Update(id uuid.UUID, updates map[string]interface{}) er
 		return nil
 	}
 
-	result := database.DB.Model(&models.Blog{}).Where("id = ?", id).Updates(updates)
-	if result.Error != nil {
-		return fmt.Errorf("failed to update blog: %w", result.Error)
+	// Publishing keeps the original publication date of a post that was
+	// published before.
+	if updates["status"] == "published" {
+		updates["published_at"] = gorm.Expr("COALESCE(published_at, ?)", time.Now())
 	}
 
-	if result.RowsAffected == 0 {
-		return fmt.Errorf("blog not found")
-	}
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if slug, ok := updates["slug"].(string); ok {
+			if err := r.recordSlugChange(tx, id, slug); err != nil {
+				return err
+			}
+		}
 
+		result := tx.Model(&models.Blog{}).Where("id = ?", id).Updates(updates)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return errBlogNotFound
+		}
+		return nil
+	})
+	if errors.Is(err, ErrSlugTaken) || errors.Is(err, errBlogNotFound) {
+		return err
+	}
+	if err != nil {
+		return fmt.Errorf("failed to update blog: %w", err)
+	}
 	return nil
 }
 
+// recordSlugChange moves the blog's current slug into its history before it
+// is replaced. Reverting to an old slug takes it back out of the history.
+func (r *BlogRepository) recordSlugChange(tx *gorm.DB, id uuid.UUID, slug string) error {
+	var current string
+	if err := tx.Model(&models.Blog{}).Select("slug").Where("id = ?", id).Scan(&current).Error; err != nil {
+		return err
+	}
+	if current == "" {
+		return errBlogNotFound
+	}
+	if current == slug {
+		return nil
+	}
+
+	if err := slugAvailable(tx, slug, id); err != nil {
+		return err
+	}
+
+	if err := tx.Where("slug = ? AND blog_id = ?", slug, id).Delete(&models.SlugHistory{}).Error; err != nil {
+		return err
+	}
+	return tx.Clauses(clause.OnConflict{DoNothing: true}).
+		Create(&models.SlugHistory{Slug: current, BlogID: id}).Error
+}
+
 func (r *BlogRepository) Delete(id uuid.UUID) error {
-	result := database.DB.Where("id = ?", id).Delete(&models.Blog{})
-	if result.Error != nil {
-		return fmt.Errorf("failed to delete blog: %w", result.Error)
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		result := tx.Where("id = ?", id).Delete(&models.Blog{})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return errBlogNotFound
+		}
+		return tx.Where("blog_id = ?", id).Delete(&models.SlugHistory{}).Error
+	})
+	if errors.Is(err, errBlogNotFound) {
+		return err
+	}
+	if err != nil {
+		return fmt.Errorf("failed to delete blog: %w", err)
+	}
+	return nil
+}
+
+// FindImageUser returns a post that still shows url, as its featured image,
+// one of its variants or somewhere in its content.
+func (r *BlogRepository) FindImageUser(url string) (uuid.UUID, bool, error) {
+	pattern := "%" + escapeLike(url) + "%"
+	var ids []uuid.UUID
+	if err := database.DB.Model(&models.Blog{}).
+		Where("featured_image = ? OR featured_image_variants::text LIKE ? OR content LIKE ?", url, pattern, pattern).
+		Limit(1).
+		Pluck("id", &ids).Error; err != nil {
+		return uuid.Nil, false, fmt.Errorf("failed to find image users: %w", err)
+	}
+	if len(ids) == 0 {
+		return uuid.Nil, false, nil
+	}
+	return ids[0], true, nil
+}
+
+func (r *BlogRepository) GetFeaturedImages() ([]*models.Blog, error) {
+	var blogs []*models.Blog
+	if err := database.DB.
+		Select("id, featured_image, featured_image_variants, featured_image_blurhash").
+		Where("featured_image IS NOT NULL AND featured_image <> ''").
+		Find(&blogs).Error; err != nil {
+		return nil, fmt.Errorf("failed to get featured images: %w", err)
 	}
+	return blogs, nil
+}
 
-	if result.RowsAffected == 0 {
-		return fmt.Errorf("blog not found")
+// SetFeaturedImageMeta stores the placeholder of a featured image, unless
+// the post has moved on to another image meanwhile. It leaves updated_at
+// alone, since the post itself did not change.
+func (r *BlogRepository) SetFeaturedImageMeta(id uuid.UUID, url string, meta models.FeaturedImageMeta) error {
+	if err := database.DB.Model(&models.Blog{}).
+		Where("id = ? AND featured_image = ?", id, url).
+		UpdateColumns(meta.Updates()).Error; err != nil {
+		return fmt.Errorf("failed to update featured image: %w", err)
 	}
+	return nil
+}
 
+// SetStats stores recomputed content statistics without touching
+// updated_at, which feeds, sitemaps and OG image versions are keyed on.
+func (r *BlogRepository) SetStats(id uuid.UUID, stats models.ContentStats) error {
+	if err := database.DB.Model(&models.Blog{}).
+		Where("id = ?", id).
+		UpdateColumns(stats.Updates()).Error; err != nil {
+		return fmt.Errorf("failed to update content stats: %w", err)
+	}
 	return nil
 }
+
+func (r *BlogRepository) publishedQuery(category, tag string) *gorm.DB {
+	query := database.DB.Model(&models.Blog{}).Where("status = ?", "published")
+	if category != "" {
+		query = query.Where("LOWER(category) = LOWER(?)", category)
+	}
+	if tag != "" {
+		tagJSON, _ := json.Marshal([]string{tag})
+		query = query.Where("tags @> ?", string(tagJSON))
+	}
+	return query
+}
+
+func (r *BlogRepository) GetPublished(category, tag string, limit int) ([]*models.Blog, error) {
+	var blogs []*models.Blog
+	if err := r.publishedQuery(category, tag).
+		Select(blogColumns).
+		Order("COALESCE(published_at, created_at) DESC").
+		Limit(limit).
+		Find(&blogs).Error; err != nil {
+		return nil, fmt.Errorf("failed to get published blogs: %w", err)
+	}
+	return blogs, nil
+}
+
+func (r *BlogRepository) LastPublishedUpdate(category, tag string) (*time.Time, int64, error) {
+	var row struct {
+		LastUpdated *time.Time
+		Total       int64
+	}
+	if err := r.publishedQuery(category, tag).
+		Select("MAX(updated_at) AS last_updated, COUNT(*) AS total").
+		Scan(&row).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to get last published update: %w", err)
+	}
+	return row.LastUpdated, row.Total, nil
+}
+
+func (r *BlogRepository) sitemapQuery() *gorm.DB {
+	return r.publishedQuery("", "").Where("no_index = ?", false)
+}
+
+func (r *BlogRepository) SitemapVersion() (*time.Time, int64, error) {
+	var row struct {
+		LastUpdated *time.Time
+		Total       int64
+	}
+	if err := r.sitemapQuery().
+		Select("MAX(updated_at) AS last_updated, COUNT(*) AS total").
+		Scan(&row).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to get sitemap version: %w", err)
+	}
+	return row.LastUpdated, row.Total, nil
+}
+
+func (r *BlogRepository) GetPublishedForSitemap(limit, offset int) ([]*models.Blog, error) {
+	var blogs []*models.Blog
+	if err := r.sitemapQuery().
+		Select("id, title, slug, featured_image, featured_image_variants, published_at, updated_at").
+		Order("COALESCE(published_at, created_at) DESC, id").
+		Limit(limit).
+		Offset(offset).
+		Find(&blogs).Error; err != nil {
+		return nil, fmt.Errorf("failed to get blogs for sitemap: %w", err)
+	}
+	return blogs, nil
+}
+
+func (r *BlogRepository) GetAllForStats() ([]*models.Blog, error) {
+	var blogs []*models.Blog
+	if err := database.DB.Select("id, content").Find(&blogs).Error; err != nil {
+		return nil, fmt.Errorf("failed to get blogs: %w", err)
+	}
+	return blogs, nil
+}
+
+func (r *BlogRepository) WordsPublishedByMonth() ([]*models.MonthlyWordStat, error) {
+	var stats []*models.MonthlyWordStat
+	if err := database.DB.Model(&models.Blog{}).
+		Select("TO_CHAR(DATE_TRUNC('month', published_at), 'YYYY-MM') AS month, COUNT(*) AS posts, SUM(word_count) AS words").
+		Where("status = ? AND published_at IS NOT NULL", "published").
+		Group("month").
+		Order("month DESC").
+		Scan(&stats).Error; err != nil {
+		return nil, fmt.Errorf("failed to get monthly word stats: %w", err)
+	}
+	return stats, nil
+}
+
+func (r *BlogRepository) LengthByCategory() ([]*models.CategoryLengthStat, error) {
+	var stats []*models.CategoryLengthStat
+	if err := database.DB.Model(&models.Blog{}).
+		Select("COALESCE(NULLIF(category, ''), 'uncategorized') AS category, COUNT(*) AS posts, "+
+			"ROUND(AVG(word_count)) AS average_words, ROUND(AVG(reading_time_minutes), 1) AS average_reading_time_minutes").
+		Where("status = ?", "published").
+		Group("1").
+		Order("posts DESC").
+		Scan(&stats).Error; err != nil {
+		return nil, fmt.Errorf("failed to get category length stats: %w", err)
+	}
+	return stats, nil
+}
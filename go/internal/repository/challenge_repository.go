@@ -0,0 +1,41 @@
+package repository
+
+import (
+	"blog-api/internal/database"
+	"blog-api/internal/models"
+	"errors"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm/clause"
+)
+
+var ErrChallengeSpent = errors.New("challenge already used")
+
+type ChallengeRepository struct{}
+
+func NewChallengeRepository() *ChallengeRepository {
+	return &ChallengeRepository{}
+}
+
+// Spend records the challenge as redeemed, or returns ErrChallengeSpent if
+// it already was.
+func (r *ChallengeRepository) Spend(nonce string, expiresAt time.Time) error {
+	result := database.DB.Clauses(clause.OnConflict{DoNothing: true}).
+		Create(&models.SpentChallenge{Nonce: nonce, ExpiresAt: expiresAt})
+	if result.Error != nil {
+		return fmt.Errorf("failed to record challenge: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return ErrChallengeSpent
+	}
+	return nil
+}
+
+func (r *ChallengeRepository) PurgeExpired(now time.Time) (int64, error) {
+	result := database.DB.Where("expires_at < ?", now).Delete(&models.SpentChallenge{})
+	if result.Error != nil {
+		return 0, fmt.Errorf("failed to purge spent challenges: %w", result.Error)
+	}
+	return result.RowsAffected, nil
+}
@@ -0,0 +1,78 @@
+package repository
+
+import (
+	"blog-api/internal/database"
+	"blog-api/internal/models"
+	"fmt"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type CommentRepository struct{}
+
+func NewCommentRepository() *CommentRepository {
+	return &CommentRepository{}
+}
+
+func (r *CommentRepository) Create(comment *models.Comment) error {
+	if err := database.DB.Create(comment).Error; err != nil {
+		return fmt.Errorf("failed to create comment: %w", err)
+	}
+	return nil
+}
+
+func (r *CommentRepository) GetByID(id uuid.UUID) (*models.Comment, error) {
+	var comment models.Comment
+	if err := database.DB.Where("id = ?", id).First(&comment).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, fmt.Errorf("comment not found")
+		}
+		return nil, fmt.Errorf("failed to get comment: %w", err)
+	}
+	return &comment, nil
+}
+
+func (r *CommentRepository) GetApprovedByBlog(blogID uuid.UUID) ([]*models.Comment, error) {
+	var comments []*models.Comment
+	if err := database.DB.
+		Where("blog_id = ? AND status = ?", blogID, models.CommentStatusApproved).
+		Order("created_at ASC").
+		Find(&comments).Error; err != nil {
+		return nil, fmt.Errorf("failed to get comments: %w", err)
+	}
+	return comments, nil
+}
+
+func (r *CommentRepository) List(status string, blogID *uuid.UUID, limit, offset int) ([]*models.Comment, int64, error) {
+	query := database.DB.Model(&models.Comment{})
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	if blogID != nil {
+		query = query.Where("blog_id = ?", *blogID)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to count comments: %w", err)
+	}
+
+	var comments []*models.Comment
+	if err := query.
+		Order("created_at DESC").
+		Limit(limit).
+		Offset(offset).
+		Find(&comments).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to get comments: %w", err)
+	}
+	return comments, total, nil
+}
+
+func (r *CommentRepository) UpdateStatus(ids []uuid.UUID, status string) (int64, error) {
+	result := database.DB.Model(&models.Comment{}).Where("id IN ?", ids).Update("status", status)
+	if result.Error != nil {
+		return 0, fmt.Errorf("failed to update comments: %w", result.Error)
+	}
+	return result.RowsAffected, nil
+}
@@ -0,0 +1,139 @@
+package repository
+
+import (
+	"blog-api/internal/database"
+	"blog-api/internal/models"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+var ErrMediaExists = errors.New("media already exists")
+
+type MediaRepository struct{}
+
+func NewMediaRepository() *MediaRepository {
+	return &MediaRepository{}
+}
+
+func (r *MediaRepository) Create(media *models.Media) error {
+	if err := database.DB.Create(media).Error; err != nil {
+		if isUniqueViolation(err) {
+			return ErrMediaExists
+		}
+		return fmt.Errorf("failed to create media: %w", err)
+	}
+	return nil
+}
+
+func (r *MediaRepository) GetByID(id uuid.UUID) (*models.Media, error) {
+	var media models.Media
+	if err := database.DB.Where("id = ?", id).First(&media).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, fmt.Errorf("media not found")
+		}
+		return nil, fmt.Errorf("failed to get media: %w", err)
+	}
+	return &media, nil
+}
+
+func (r *MediaRepository) GetByURL(url string) (*models.Media, error) {
+	var media models.Media
+	if err := database.DB.Where("url = ?", url).First(&media).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, fmt.Errorf("media not found")
+		}
+		return nil, fmt.Errorf("failed to get media: %w", err)
+	}
+	return &media, nil
+}
+
+// List filters by a free-text search over filename and alt text, and by MIME
+// type; a mimeType ending in "/" (e.g. "image/") matches the whole family.
+func (r *MediaRepository) List(search, mimeType string, limit, offset int) ([]*models.Media, int64, error) {
+	query := database.DB.Model(&models.Media{})
+	if search != "" {
+		pattern := "%" + escapeLike(search) + "%"
+		query = query.Where("filename ILIKE ? OR alt_text ILIKE ?", pattern, pattern)
+	}
+	if strings.HasSuffix(mimeType, "/") {
+		query = query.Where("mime_type LIKE ?", escapeLike(mimeType)+"%")
+	} else if mimeType != "" {
+		query = query.Where("mime_type = ?", mimeType)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to count media: %w", err)
+	}
+
+	var media []*models.Media
+	if err := query.
+		Order("created_at DESC").
+		Limit(limit).
+		Offset(offset).
+		Find(&media).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to get media: %w", err)
+	}
+	return media, total, nil
+}
+
+func (r *MediaRepository) GetByStorage(storage string) ([]*models.Media, error) {
+	var media []*models.Media
+	if err := database.DB.Select("id, public_id, variants").Where("storage = ?", storage).Find(&media).Error; err != nil {
+		return nil, fmt.Errorf("failed to get media: %w", err)
+	}
+	return media, nil
+}
+
+func (r *MediaRepository) GetAllImages() ([]*models.Media, error) {
+	var media []*models.Media
+	if err := database.DB.
+		Select("id, url, public_id, storage, width, height, blurhash, dominant_color").
+		Where("mime_type LIKE ?", "image/%").
+		Find(&media).Error; err != nil {
+		return nil, fmt.Errorf("failed to get media: %w", err)
+	}
+	return media, nil
+}
+
+func (r *MediaRepository) SetPlaceholder(id uuid.UUID, placeholder *models.ImagePlaceholder) error {
+	if err := database.DB.Model(&models.Media{}).Where("id = ?", id).UpdateColumns(map[string]interface{}{
+		"blurhash":       placeholder.Blurhash,
+		"dominant_color": placeholder.DominantColor,
+		"width":          placeholder.Width,
+		"height":         placeholder.Height,
+	}).Error; err != nil {
+		return fmt.Errorf("failed to update media: %w", err)
+	}
+	return nil
+}
+
+func (r *MediaRepository) Update(id uuid.UUID, updates map[string]interface{}) error {
+	result := database.DB.Model(&models.Media{}).Where("id = ?", id).Updates(updates)
+	if result.Error != nil {
+		return fmt.Errorf("failed to update media: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("media not found")
+	}
+	return nil
+}
+
+func (r *MediaRepository) Delete(id uuid.UUID) error {
+	result := database.DB.Where("id = ?", id).Delete(&models.Media{})
+	if result.Error != nil {
+		return fmt.Errorf("failed to delete media: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("media not found")
+	}
+	return nil
+}
+
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
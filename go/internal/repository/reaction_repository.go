@@ -0,0 +1,106 @@
+package repository
+
+import (
+	"blog-api/internal/database"
+	"blog-api/internal/models"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type ReactionRepository struct{}
+
+func NewReactionRepository() *ReactionRepository {
+	return &ReactionRepository{}
+}
+
+// Add returns false when the visitor has already left this reaction. A new
+// reaction is also logged as an event for the hourly limit.
+func (r *ReactionRepository) Add(reaction *models.Reaction) (bool, error) {
+	created := false
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		result := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(reaction)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return nil
+		}
+		created = true
+		return tx.Create(&models.ReactionEvent{VisitorHash: reaction.VisitorHash}).Error
+	})
+	if err != nil {
+		return false, fmt.Errorf("failed to add reaction: %w", err)
+	}
+	return created, nil
+}
+
+func (r *ReactionRepository) Remove(blogID uuid.UUID, visitorHash, reactionType string) error {
+	if err := database.DB.
+		Where("blog_id = ? AND visitor_hash = ? AND type = ?", blogID, visitorHash, reactionType).
+		Delete(&models.Reaction{}).Error; err != nil {
+		return fmt.Errorf("failed to remove reaction: %w", err)
+	}
+	return nil
+}
+
+// CountRecentByVisitor counts the reactions the visitor added since the
+// given time, including ones already taken back.
+func (r *ReactionRepository) CountRecentByVisitor(visitorHash string, since time.Time) (int64, error) {
+	var count int64
+	if err := database.DB.Model(&models.ReactionEvent{}).
+		Where("visitor_hash = ? AND created_at > ?", visitorHash, since).
+		Count(&count).Error; err != nil {
+		return 0, fmt.Errorf("failed to count reactions: %w", err)
+	}
+	return count, nil
+}
+
+func (r *ReactionRepository) PurgeEvents(before time.Time) (int64, error) {
+	result := database.DB.Where("created_at < ?", before).Delete(&models.ReactionEvent{})
+	if result.Error != nil {
+		return 0, fmt.Errorf("failed to purge reaction events: %w", result.Error)
+	}
+	return result.RowsAffected, nil
+}
+
+func (r *ReactionRepository) VisitorReactions(blogID uuid.UUID, visitorHash string) ([]string, error) {
+	types := []string{}
+	if err := database.DB.Model(&models.Reaction{}).
+		Where("blog_id = ? AND visitor_hash = ?", blogID, visitorHash).
+		Pluck("type", &types).Error; err != nil {
+		return nil, fmt.Errorf("failed to get visitor reactions: %w", err)
+	}
+	return types, nil
+}
+
+func (r *ReactionRepository) CountsForBlogs(blogIDs []uuid.UUID) (map[uuid.UUID]map[string]int64, error) {
+	counts := make(map[uuid.UUID]map[string]int64, len(blogIDs))
+	if len(blogIDs) == 0 {
+		return counts, nil
+	}
+
+	var rows []struct {
+		BlogID uuid.UUID
+		Type   string
+		Count  int64
+	}
+	if err := database.DB.Model(&models.Reaction{}).
+		Select("blog_id, type, COUNT(*) AS count").
+		Where("blog_id IN ?", blogIDs).
+		Group("blog_id, type").
+		Scan(&rows).Error; err != nil {
+		return nil, fmt.Errorf("failed to count reactions: %w", err)
+	}
+
+	for _, row := range rows {
+		if counts[row.BlogID] == nil {
+			counts[row.BlogID] = make(map[string]int64)
+		}
+		counts[row.BlogID][row.Type] = row.Count
+	}
+	return counts, nil
+}
@@ -0,0 +1,45 @@
+package repository
+
+import (
+	"blog-api/internal/database"
+	"blog-api/internal/models"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type SpamRepository struct{}
+
+func NewSpamRepository() *SpamRepository {
+	return &SpamRepository{}
+}
+
+func (r *SpamRepository) GetSettings() (*models.SpamSettings, error) {
+	var settings models.SpamSettings
+	if err := database.DB.Where("id = ?", 1).First(&settings).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return models.DefaultSpamSettings(), nil
+		}
+		return nil, fmt.Errorf("failed to get spam settings: %w", err)
+	}
+	return &settings, nil
+}
+
+func (r *SpamRepository) SaveSettings(settings *models.SpamSettings) error {
+	settings.ID = 1
+	if err := database.DB.Save(settings).Error; err != nil {
+		return fmt.Errorf("failed to save spam settings: %w", err)
+	}
+	return nil
+}
+
+func (r *SpamRepository) CountRecentDuplicates(contentHash string, since time.Time) (int64, error) {
+	var count int64
+	if err := database.DB.Model(&models.Comment{}).
+		Where("content_hash = ? AND created_at > ?", contentHash, since).
+		Count(&count).Error; err != nil {
+		return 0, fmt.Errorf("failed to count duplicate comments: %w", err)
+	}
+	return count, nil
+}
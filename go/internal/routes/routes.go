@@ -4,23 +4,78 @@ import (
 	"blog-api/internal/handlers"
 	"blog-api/internal/middleware"
 	"blog-api/internal/services"
+	"blog-api/internal/utils"
 	"log"
 	"os"
 
 	"github.com/gin-gonic/gin"
 )
 
-func SetupRoutes(router *gin.Engine) {
-	var cloudinaryService *services.CloudinaryService
-	cloudinaryService, err := services.NewCloudinaryService()
+func SetupRoutes(router *gin.Engine) error {
+	if err := utils.InitAnalyticsSecret(); err != nil {
+		return err
+	}
+	if err := utils.CheckSiteURL(); err != nil {
+		return err
+	}
+
+	storage, err := services.NewStorage()
 	if err != nil {
-		log.Printf("Warning: Cloudinary service initialization failed: %v. Image upload will not be available.", err)
+		return err
 	}
+	log.Printf("Media storage: %s", storage.Name())
+	assetService := services.NewAssetService(storage)
+	imageService := services.NewImageService()
 
-	blogHandler := handlers.NewBlogHandler(cloudinaryService)
+	sanitizer := services.NewSanitizerService()
+	markdownService := services.NewMarkdownService(sanitizer)
+	blogHandler := handlers.NewBlogHandler(assetService, imageService, markdownService, sanitizer)
 	authHandler := handlers.NewAuthHandler()
+	analyticsHandler := handlers.NewAnalyticsHandler()
+	reactionHandler := handlers.NewReactionHandler()
+	spamService := services.NewSpamService()
+	commentHandler := handlers.NewCommentHandler(spamService)
+	spamHandler := handlers.NewSpamHandler(spamService)
+	powService, err := services.NewProofOfWorkService(middleware.RateLimitPressure)
+	if err != nil {
+		return err
+	}
+	challengeHandler := handlers.NewChallengeHandler(powService)
+	proofOfWork := middleware.ProofOfWork(powService)
+	feedHandler := handlers.NewFeedHandler(markdownService)
+	sitemapHandler := handlers.NewSitemapHandler()
+	highlightHandler := handlers.NewHighlightHandler()
+	statsHandler := handlers.NewStatsHandler(markdownService)
+	ogImageService, err := services.NewOGImageService()
+	if err != nil {
+		log.Printf("Warning: OG image service initialization failed: %v. Generated preview images will not be available.", err)
+	}
+	seoHandler := handlers.NewSEOHandler(markdownService, ogImageService)
+	directUploadService, err := services.NewDirectUploadService(storage, imageService)
+	if err != nil {
+		return err
+	}
+	mediaHandler := handlers.NewMediaHandler(assetService, imageService, directUploadService)
+	storageHandler := handlers.NewStorageHandler(assetService)
 	authRoutePath := os.Getenv("AUTH_ROUTE_PATH")
 
+	if local, ok := storage.(*services.LocalStorage); ok {
+		router.StaticFS(services.LocalMediaPath, local.PublicFS())
+		// Signed direct uploads; the signature in the URL replaces the API key.
+		router.PUT(services.LocalUploadPath+"/*key", middleware.RateLimit(), mediaHandler.ReceiveUpload)
+	}
+
+	feeds := router.Group("")
+	feeds.Use(middleware.RateLimit())
+	{
+		feeds.GET("/feed.xml", feedHandler.RSS)
+		feeds.GET("/atom.xml", feedHandler.Atom)
+		feeds.GET("/feed.json", feedHandler.JSONFeed)
+		feeds.GET("/sitemap.xml", sitemapHandler.Sitemap)
+		feeds.GET("/sitemaps/:page", sitemapHandler.SitemapPage)
+		feeds.GET("/robots.txt", sitemapHandler.Robots)
+	}
+
 	api := router.Group("/api/v1")
 	{
 		api.GET("/health", func(c *gin.Context) {
@@ -30,6 +85,8 @@ func SetupRoutes(router *gin.Engine) {
 		blogs := api.Group("/blogs")
 		blogs.Use(middleware.APIKeyAuth())
 		blogs.Use(middleware.RateLimit())
+		// Multipart bodies may carry images; anything else gets the regular limit.
+		blogs.Use(middleware.UploadBodyLimit())
 		{
 			blogs.POST("", blogHandler.CreateBlog)
 			blogs.GET("", blogHandler.GetAllBlogs)
@@ -39,11 +96,82 @@ func SetupRoutes(router *gin.Engine) {
 			blogs.DELETE("/:id", blogHandler.DeleteBlog)
 		}
 
+		media := api.Group("/media")
+		media.Use(middleware.APIKeyAuth())
+		media.Use(middleware.RateLimit())
+		media.Use(middleware.UploadBodyLimit())
+		{
+			media.POST("", mediaHandler.UploadMedia)
+			media.POST("/uploads", mediaHandler.IssueUpload)
+			media.POST("/uploads/confirm", mediaHandler.ConfirmUpload)
+			media.GET("", mediaHandler.ListMedia)
+			media.GET("/:id", mediaHandler.GetMedia)
+			media.PUT("/:id", mediaHandler.UpdateMedia)
+			media.DELETE("/:id", mediaHandler.DeleteMedia)
+		}
+
+		storageAdmin := api.Group("/storage")
+		storageAdmin.Use(middleware.APIKeyAuth())
+		storageAdmin.Use(middleware.RateLimit())
+		storageAdmin.Use(middleware.BodyLimit())
+		{
+			storageAdmin.POST("/reconcile", storageHandler.Reconcile)
+		}
+
+		analytics := api.Group("/analytics")
+		analytics.Use(middleware.APIKeyAuth())
+		analytics.Use(middleware.RateLimit())
+		analytics.Use(middleware.BodyLimit())
+		{
+			analytics.GET("/top-posts", analyticsHandler.GetTopPosts)
+			analytics.GET("/referrers", analyticsHandler.GetTopReferrers)
+			analytics.GET("/blogs/:id/views", analyticsHandler.GetBlogViews)
+		}
+
+		stats := api.Group("/stats")
+		stats.Use(middleware.APIKeyAuth())
+		stats.Use(middleware.RateLimit())
+		stats.Use(middleware.BodyLimit())
+		{
+			stats.GET("/content", statsHandler.GetContentStats)
+			stats.POST("/content/recompute", statsHandler.RecomputeStats)
+		}
+
+		comments := api.Group("/comments")
+		comments.Use(middleware.APIKeyAuth())
+		comments.Use(middleware.RateLimit())
+		comments.Use(middleware.BodyLimit())
+		{
+			comments.GET("", commentHandler.ListComments)
+			comments.PUT("/moderate", commentHandler.ModerateComments)
+			comments.GET("/spam-settings", spamHandler.GetSettings)
+			comments.PUT("/spam-settings", spamHandler.UpdateSettings)
+		}
+
+		public := api.Group("/public")
+		public.Use(middleware.RateLimit())
+		public.Use(middleware.BodyLimit())
+		{
+			public.GET("/challenge", challengeHandler.IssueChallenge)
+			public.GET("/highlight.css", highlightHandler.Stylesheet)
+			public.GET("/blogs/:slug/meta", seoHandler.GetBlogMeta)
+			public.GET("/blogs/:slug/og-image.png", seoHandler.GetBlogOGImage)
+			public.POST("/blogs/:slug/views", analyticsHandler.RecordView)
+			public.GET("/blogs/:slug/reactions", reactionHandler.GetReactions)
+			public.POST("/blogs/:slug/reactions", middleware.ReactionRateLimit(), proofOfWork, reactionHandler.AddReaction)
+			public.DELETE("/blogs/:slug/reactions/:type", middleware.ReactionRateLimit(), proofOfWork, reactionHandler.RemoveReaction)
+			public.GET("/blogs/:slug/comments", commentHandler.GetBlogComments)
+			public.POST("/blogs/:slug/comments", proofOfWork, commentHandler.CreateComment)
+		}
+
 		if authRoutePath != "" {
 			auth := api.Group(authRoutePath)
+			auth.Use(middleware.BodyLimit())
 			{
 				auth.POST("/admin", middleware.APIKeyAuth(), authHandler.AdminLogin)
 			}
 		}
 	}
+
+	return nil
 }
@@ -0,0 +1,333 @@
+package services
+
+import (
+	"blog-api/internal/models"
+	"blog-api/internal/repository"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const deleteAttempts = 3
+
+// Key prefixes of everything the API uploads. Inline images are kept apart
+// from featured images so they can be told apart among a post's assets.
+const (
+	BlogImagePrefix   = "blogs/"
+	MediaPrefix       = "media/"
+	InlineImagePrefix = "inline/"
+)
+
+// assetPrefixes are the only places Reconcile looks, so other data sharing
+// the bucket or folder is never reported or purged.
+var assetPrefixes = []string{BlogImagePrefix, MediaPrefix, InlineImagePrefix, DirectUploadPrefix}
+
+// AssetService uploads files on behalf of an owner (a blog or a media
+// library entry) and removes them from storage when the owner goes away.
+type AssetService struct {
+	storage   Storage
+	repo      *repository.AssetRepository
+	blogRepo  *repository.BlogRepository
+	mediaRepo *repository.MediaRepository
+}
+
+func NewAssetService(storage Storage) *AssetService {
+	return &AssetService{
+		storage:   storage,
+		repo:      repository.NewAssetRepository(),
+		blogRepo:  repository.NewBlogRepository(),
+		mediaRepo: repository.NewMediaRepository(),
+	}
+}
+
+func (s *AssetService) Storage() Storage {
+	return s.storage
+}
+
+// Upload stores the file and records its owner. When the record cannot be
+// written the object is removed again rather than left untracked.
+func (s *AssetService) Upload(ctx context.Context, key string, r io.Reader, size int64, contentType, ownerType string, ownerID uuid.UUID) (*StoredObject, error) {
+	stored, err := s.storage.Put(ctx, key, r, size, contentType)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.Save(&models.Asset{
+		Key:       key,
+		URL:       stored.URL,
+		Storage:   s.storage.Name(),
+		OwnerType: ownerType,
+		OwnerID:   &ownerID,
+	}); err != nil {
+		s.deleteWithRetry(context.WithoutCancel(ctx), key)
+		return nil, err
+	}
+	return stored, nil
+}
+
+// Release deletes an asset from storage and forgets it. If storage keeps
+// failing, the record stays behind for the reconcile job to retry.
+func (s *AssetService) Release(ctx context.Context, key string) error {
+	ctx = context.WithoutCancel(ctx)
+	if err := s.deleteWithRetry(ctx, key); err != nil {
+		log.Printf("Warning: failed to delete asset %s: %v", key, err)
+		return err
+	}
+	return s.repo.Delete(key)
+}
+
+func (s *AssetService) ReleaseOwner(ctx context.Context, ownerType string, ownerID uuid.UUID) error {
+	return s.releaseOwned(ctx, ownerType, ownerID, func(*models.Asset) bool { return false })
+}
+
+// ReleaseUnused releases the assets of a deleted post, except ones that
+// another post still shows. Those are left without an owner, and the
+// reconcile job hands them to the post that uses them.
+func (s *AssetService) ReleaseUnused(ctx context.Context, ownerType string, ownerID uuid.UUID) error {
+	return s.releaseOwned(ctx, ownerType, ownerID, func(asset *models.Asset) bool {
+		_, used, err := s.blogRepo.FindImageUser(asset.URL)
+		return err != nil || used
+	})
+}
+
+func (s *AssetService) releaseOwned(ctx context.Context, ownerType string, ownerID uuid.UUID, keep func(*models.Asset) bool) error {
+	assets, err := s.repo.GetByOwner(ownerType, ownerID)
+	if err != nil {
+		return err
+	}
+
+	var failed []string
+	for _, asset := range assets {
+		if keep(asset) {
+			continue
+		}
+		if err := s.Release(ctx, asset.Key); err != nil {
+			failed = append(failed, asset.Key)
+		}
+	}
+	if len(failed) > 0 {
+		return fmt.Errorf("failed to delete assets: %s", strings.Join(failed, ", "))
+	}
+	return nil
+}
+
+func (s *AssetService) Owned(ownerType string, ownerID uuid.UUID) ([]*models.Asset, error) {
+	return s.repo.GetByOwner(ownerType, ownerID)
+}
+
+// Owner reports who owns the asset behind a public URL, if anyone.
+func (s *AssetService) Owner(url string) (*models.Asset, bool) {
+	key, ok := s.storage.Key(url)
+	if !ok {
+		return nil, false
+	}
+	asset, err := s.repo.GetByKey(key)
+	if err != nil {
+		return nil, false
+	}
+	return asset, true
+}
+
+func (s *AssetService) deleteWithRetry(ctx context.Context, key string) error {
+	var err error
+	for attempt := 0; attempt < deleteAttempts; attempt++ {
+		if attempt > 0 {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(time.Duration(attempt*attempt) * 250 * time.Millisecond):
+			}
+		}
+		err = s.storage.Delete(ctx, key)
+		if err == nil || errors.Is(err, ErrObjectNotFound) {
+			return nil
+		}
+	}
+	return err
+}
+
+// Reconcile compares what is in storage below the API's own prefixes with
+// what the database references.
+// Objects nobody owns, and assets whose owner has been deleted, are reported
+// as orphans and removed when purge is set. Objects younger than minAge are
+// skipped so uploads still in flight are not mistaken for orphans. Untracked
+// objects that a post or media entry still points at are adopted instead.
+func (s *AssetService) Reconcile(ctx context.Context, purge bool, minAge time.Duration) (*models.ReconcileReport, error) {
+	report := &models.ReconcileReport{
+		Storage: s.storage.Name(),
+		Purge:   purge,
+		Adopted: []string{},
+		Orphans: []*models.OrphanAsset{},
+		Missing: []string{},
+		Purged:  []string{},
+		Failed:  []string{},
+	}
+
+	var objects []*StoredObject
+	for _, prefix := range assetPrefixes {
+		listed, err := s.storage.List(ctx, prefix)
+		if err != nil {
+			return nil, err
+		}
+		objects = append(objects, listed...)
+	}
+	report.Scanned = len(objects)
+
+	assets, err := s.repo.GetByStorage(s.storage.Name())
+	if err != nil {
+		return nil, err
+	}
+	dangling, err := s.repo.GetDangling(s.storage.Name())
+	if err != nil {
+		return nil, err
+	}
+	references, err := s.references()
+	if err != nil {
+		return nil, err
+	}
+
+	// Backends may report a different extension than the one uploaded
+	// (Cloudinary normalises .jpeg to .jpg), so keys are matched without it.
+	tracked := make(map[string]*models.Asset, len(assets))
+	for _, asset := range assets {
+		tracked[stem(asset.Key)] = asset
+	}
+	orphaned := make(map[string]bool, len(dangling))
+	for _, asset := range dangling {
+		orphaned[stem(asset.Key)] = true
+	}
+
+	cutoff := time.Now().Add(-minAge)
+	seen := make(map[string]bool, len(objects))
+	for _, object := range objects {
+		id := stem(object.Key)
+		seen[id] = true
+		if object.ModTime.After(cutoff) {
+			continue
+		}
+
+		if asset, ok := tracked[id]; ok {
+			if !orphaned[id] {
+				report.Referenced++
+				continue
+			}
+			// A post may still point at an asset whose original owner is gone.
+			if ref, ok := references[id]; ok {
+				s.adopt(report, object, asset.Key, ref)
+				continue
+			}
+			blogID, used, err := s.blogRepo.FindImageUser(asset.URL)
+			if err != nil {
+				report.Failed = append(report.Failed, asset.Key)
+				continue
+			}
+			if used {
+				s.adopt(report, object, asset.Key, assetReference{models.AssetOwnerBlog, blogID})
+				continue
+			}
+			s.orphan(ctx, report, object, asset.Key, "owner deleted")
+			continue
+		}
+
+		if ref, ok := references[id]; ok {
+			s.adopt(report, object, object.Key, ref)
+			continue
+		}
+		s.orphan(ctx, report, object, object.Key, "untracked")
+	}
+
+	for id, asset := range tracked {
+		if seen[id] {
+			continue
+		}
+		report.Missing = append(report.Missing, asset.Key)
+		if purge && orphaned[id] {
+			s.repo.Delete(asset.Key)
+		}
+	}
+
+	return report, nil
+}
+
+type assetReference struct {
+	ownerType string
+	ownerID   uuid.UUID
+}
+
+func (s *AssetService) references() (map[string]assetReference, error) {
+	refs := make(map[string]assetReference)
+
+	images, err := s.blogRepo.GetFeaturedImages()
+	if err != nil {
+		return nil, err
+	}
+	for _, blog := range images {
+		urls := []string{*blog.FeaturedImage}
+		for _, variant := range blog.FeaturedImageVariants {
+			urls = append(urls, variant.URL)
+		}
+		for _, url := range urls {
+			if key, ok := s.storage.Key(url); ok {
+				refs[stem(key)] = assetReference{models.AssetOwnerBlog, blog.ID}
+			}
+		}
+	}
+
+	media, err := s.mediaRepo.GetByStorage(s.storage.Name())
+	if err != nil {
+		return nil, err
+	}
+	for _, m := range media {
+		refs[stem(m.PublicID)] = assetReference{models.AssetOwnerMedia, m.ID}
+		for _, variant := range m.Variants {
+			if key, ok := s.storage.Key(variant.URL); ok {
+				refs[stem(key)] = assetReference{models.AssetOwnerMedia, m.ID}
+			}
+		}
+	}
+	return refs, nil
+}
+
+func (s *AssetService) adopt(report *models.ReconcileReport, object *StoredObject, key string, ref assetReference) {
+	if err := s.repo.Save(&models.Asset{
+		Key:       key,
+		URL:       object.URL,
+		Storage:   s.storage.Name(),
+		OwnerType: ref.ownerType,
+		OwnerID:   &ref.ownerID,
+	}); err != nil {
+		report.Failed = append(report.Failed, key)
+		return
+	}
+	report.Adopted = append(report.Adopted, key)
+	report.Referenced++
+}
+
+func (s *AssetService) orphan(ctx context.Context, report *models.ReconcileReport, object *StoredObject, key, reason string) {
+	report.Orphans = append(report.Orphans, &models.OrphanAsset{
+		Key:     key,
+		URL:     object.URL,
+		Size:    object.Size,
+		ModTime: object.ModTime,
+		Reason:  reason,
+	})
+	if !report.Purge {
+		return
+	}
+	if err := s.Release(ctx, key); err != nil {
+		report.Failed = append(report.Failed, key)
+		return
+	}
+	report.Purged = append(report.Purged, key)
+}
+
+func stem(key string) string {
+	return strings.TrimSuffix(key, path.Ext(key))
+}
@@ -4,14 +4,22 @@ import (
 	"context"
 	"fmt"
 	"io"
+	"net/http"
+	"net/url"
 	"os"
+	"path"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/cloudinary/cloudinary-go/v2"
+	"github.com/cloudinary/cloudinary-go/v2/api"
+	"github.com/cloudinary/cloudinary-go/v2/api/admin"
 	"github.com/cloudinary/cloudinary-go/v2/api/uploader"
 )
 
 type CloudinaryService struct {
-	cld *cloudinary.Cloudinary
+	cld    *cloudinary.Cloudinary
 	folder string
 }
 
@@ -33,17 +41,205 @@ func NewCloudinaryService() (*CloudinaryService, error) {
 	return &CloudinaryService{cld: cld, folder: folder}, nil
 }
 
-func (s *CloudinaryService) UploadImage(ctx context.Context, file io.Reader, publicID string) (string, error) {
-	uploadParams := uploader.UploadParams{
-		Folder:   s.folder,
-		PublicID: publicID,
+func (s *CloudinaryService) Name() string {
+	return "cloudinary"
+}
+
+// publicID maps a storage key to a Cloudinary public ID. Cloudinary keeps
+// the format separately, so the extension is dropped.
+func (s *CloudinaryService) publicID(key string) (string, error) {
+	key, err := cleanKey(key)
+	if err != nil {
+		return "", err
+	}
+	return path.Join(s.folder, strings.TrimSuffix(key, path.Ext(key))), nil
+}
+
+func (s *CloudinaryService) Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) (*StoredObject, error) {
+	publicID, err := s.publicID(key)
+	if err != nil {
+		return nil, err
 	}
 
-	result, err := s.cld.Upload.Upload(ctx, file, uploadParams)
+	overwrite := true
+	result, err := s.cld.Upload.Upload(ctx, r, uploader.UploadParams{
+		PublicID:  publicID,
+		Overwrite: &overwrite,
+	})
 	if err != nil {
-		return "", fmt.Errorf("failed to upload image to Cloudinary: %w", err)
+		return nil, fmt.Errorf("failed to upload image to Cloudinary: %w", err)
+	}
+	if result.Error.Message != "" {
+		return nil, fmt.Errorf("failed to upload image to Cloudinary: %s", result.Error.Message)
 	}
 
-	return result.SecureURL, nil
+	return &StoredObject{
+		Key:         key,
+		URL:         result.SecureURL,
+		Size:        int64(result.Bytes),
+		ContentType: contentType,
+		ModTime:     result.CreatedAt,
+	}, nil
 }
 
+func (s *CloudinaryService) Delete(ctx context.Context, key string) error {
+	publicID, err := s.publicID(key)
+	if err != nil {
+		return err
+	}
+
+	result, err := s.cld.Upload.Destroy(ctx, uploader.DestroyParams{PublicID: publicID})
+	if err != nil {
+		return fmt.Errorf("failed to delete image from Cloudinary: %w", err)
+	}
+	if result.Error.Message != "" {
+		return fmt.Errorf("failed to delete image from Cloudinary: %s", result.Error.Message)
+	}
+	if result.Result == "not found" {
+		return ErrObjectNotFound
+	}
+	return nil
+}
+
+// Open downloads the asset through its public delivery URL.
+func (s *CloudinaryService) Open(ctx context.Context, key string) (io.ReadCloser, error) {
+	url := s.URL(key)
+	if url == "" {
+		return nil, fmt.Errorf("invalid key %q", key)
+	}
+	return fetchURL(ctx, url)
+}
+
+// PresignUpload returns signed parameters for Cloudinary's upload API.
+// Cloudinary accepts a signature for an hour and cannot be told a size or
+// type limit, so both are only enforced when the upload is confirmed.
+func (s *CloudinaryService) PresignUpload(ctx context.Context, key, contentType string, maxBytes int64, expiresAt time.Time) (*DirectUpload, error) {
+	publicID, err := s.publicID(key)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	if latest := now.Add(time.Hour); expiresAt.After(latest) {
+		expiresAt = latest
+	}
+	params := url.Values{
+		"public_id": {publicID},
+		"timestamp": {strconv.FormatInt(now.Unix(), 10)},
+	}
+	cloud := s.cld.Config.Cloud
+	signature, err := api.SignParametersUsingAlgo(params, cloud.APISecret, cloud.GetSignatureAlgorithm())
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign Cloudinary upload: %w", err)
+	}
+
+	fields := map[string]string{"api_key": cloud.APIKey, "signature": signature}
+	for name := range params {
+		fields[name] = params.Get(name)
+	}
+	if cloud.GetSignatureAlgorithm() != "sha1" {
+		fields["signature_algorithm"] = cloud.GetSignatureAlgorithm()
+	}
+	return &DirectUpload{
+		Method:    http.MethodPost,
+		URL:       s.cld.Config.API.UploadPrefix + "/v1_1/" + cloud.CloudName + "/image/upload",
+		Fields:    fields,
+		FileField: "file",
+		ExpiresAt: expiresAt,
+	}, nil
+}
+
+func (s *CloudinaryService) URL(key string) string {
+	publicID, err := s.publicID(key)
+	if err != nil {
+		return ""
+	}
+	image, err := s.cld.Image(publicID)
+	if err != nil {
+		return ""
+	}
+	url, err := image.String()
+	if err != nil {
+		return ""
+	}
+	return url + path.Ext(key)
+}
+
+func (s *CloudinaryService) List(ctx context.Context, prefix string) ([]*StoredObject, error) {
+	var objects []*StoredObject
+	cursor := ""
+	for {
+		result, err := s.cld.Admin.Assets(ctx, admin.AssetsParams{
+			DeliveryType: "upload",
+			Prefix:       s.folder + "/" + prefix,
+			MaxResults:   500,
+			NextCursor:   cursor,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to list images on Cloudinary: %w", err)
+		}
+		if result.Error.Message != "" {
+			return nil, fmt.Errorf("failed to list images on Cloudinary: %s", result.Error.Message)
+		}
+
+		for _, asset := range result.Assets {
+			key := strings.TrimPrefix(asset.PublicID, s.folder+"/")
+			if asset.Format != "" {
+				key += "." + asset.Format
+			}
+			objects = append(objects, &StoredObject{
+				Key:         key,
+				URL:         asset.SecureURL,
+				Size:        int64(asset.Bytes),
+				ContentType: "image/" + asset.Format,
+				ModTime:     asset.CreatedAt,
+			})
+		}
+
+		if result.NextCursor == "" {
+			return objects, nil
+		}
+		cursor = result.NextCursor
+	}
+}
+
+// Key parses delivery URLs of the form
+// https://res.cloudinary.com/<cloud>/image/upload/v<version>/<folder>/<id>.<ext>.
+func (s *CloudinaryService) Key(url string) (string, bool) {
+	_, rest, ok := strings.Cut(url, "/image/upload/")
+	if !ok {
+		return "", false
+	}
+	if version, after, found := strings.Cut(rest, "/"); found && len(version) > 1 && version[0] == 'v' {
+		if _, err := strconv.Atoi(version[1:]); err == nil {
+			rest = after
+		}
+	}
+	return keyAfter("/"+rest, "/"+s.folder)
+}
+
+func (s *CloudinaryService) Stat(ctx context.Context, key string) (*StoredObject, error) {
+	publicID, err := s.publicID(key)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := s.cld.Admin.Asset(ctx, admin.AssetParams{PublicID: publicID})
+	if err != nil {
+		return nil, fmt.Errorf("failed to stat image on Cloudinary: %w", err)
+	}
+	if result.Error.Message != "" {
+		if strings.Contains(strings.ToLower(result.Error.Message), "not found") {
+			return nil, ErrObjectNotFound
+		}
+		return nil, fmt.Errorf("failed to stat image on Cloudinary: %s", result.Error.Message)
+	}
+
+	return &StoredObject{
+		Key:         key,
+		URL:         result.SecureURL,
+		Size:        int64(result.Bytes),
+		ContentType: "image/" + result.Format,
+		ModTime:     result.CreatedAt,
+	}, nil
+}
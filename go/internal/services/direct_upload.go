@@ -0,0 +1,191 @@
+package services
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// DirectUploadPrefix is where clients put files before they are confirmed.
+// Confirming copies the validated image to its final key, and media records
+// only ever point there, so whatever is written here afterwards never ends
+// up in the library. Local storage does not serve this prefix at all.
+const DirectUploadPrefix = "incoming/"
+
+// directUploadGrace is how long after the upload URL expires the upload can
+// still be confirmed, for clients that finish a slow upload at the deadline.
+const directUploadGrace = time.Hour
+
+var (
+	ErrDirectUploadUnsupported = errors.New("storage backend does not support direct uploads")
+	ErrUploadTokenInvalid      = errors.New("invalid upload token")
+	ErrUploadTokenExpired      = errors.New("upload token expired")
+)
+
+// DirectUpload tells a client how to send a file straight to storage: a PUT
+// of the raw body with Headers, or a multipart POST of Fields followed by
+// the file in FileField.
+type DirectUpload struct {
+	Method    string            `json:"method"`
+	URL       string            `json:"url"`
+	Headers   map[string]string `json:"headers,omitempty"`
+	Fields    map[string]string `json:"fields,omitempty"`
+	FileField string            `json:"file_field,omitempty"`
+	ExpiresAt time.Time         `json:"expires_at"`
+}
+
+// DirectUploader is implemented by backends that accept uploads from
+// clients without the file passing through the API.
+type DirectUploader interface {
+	PresignUpload(ctx context.Context, key, contentType string, maxBytes int64, expiresAt time.Time) (*DirectUpload, error)
+}
+
+// DirectUploadClaims is what an upload token vouches for.
+type DirectUploadClaims struct {
+	ID          uuid.UUID `json:"id"`
+	Key         string    `json:"key"`
+	Filename    string    `json:"filename"`
+	ContentType string    `json:"content_type"`
+	UploadedBy  string    `json:"uploaded_by"`
+	ExpiresAt   time.Time `json:"expires_at"`
+}
+
+type DirectUploadTicket struct {
+	ID        uuid.UUID     `json:"id"`
+	Key       string        `json:"key"`
+	Token     string        `json:"token"`
+	Upload    *DirectUpload `json:"upload"`
+	ExpiresAt time.Time     `json:"expires_at"`
+}
+
+// DirectUploadService hands out signed upload parameters for the storage
+// backend, together with a token that the confirmation step checks, so only
+// uploads the API asked for can be registered.
+type DirectUploadService struct {
+	storage Storage
+	images  *ImageService
+	secret  []byte
+	ttl     time.Duration
+}
+
+func NewDirectUploadService(storage Storage, images *ImageService) (*DirectUploadService, error) {
+	secret, err := uploadSecret()
+	if err != nil {
+		return nil, err
+	}
+
+	return &DirectUploadService{
+		storage: storage,
+		images:  images,
+		secret:  secret,
+		ttl:     time.Duration(envInt("UPLOAD_URL_TTL_SECONDS", 900)) * time.Second,
+	}, nil
+}
+
+// uploadSecret signs upload tokens and local upload URLs. It has to be the
+// same across restarts and replicas, or outstanding uploads stop working.
+func uploadSecret() ([]byte, error) {
+	secret := os.Getenv("UPLOAD_SECRET")
+	if secret == "" {
+		return nil, fmt.Errorf("UPLOAD_SECRET not set")
+	}
+	return []byte(secret), nil
+}
+
+// Issue prepares a direct upload of one image. The declared content type
+// and size are checked up front; the file itself is validated on confirm.
+func (s *DirectUploadService) Issue(ctx context.Context, filename, contentType string, size int64, uploadedBy string) (*DirectUploadTicket, error) {
+	uploader, ok := s.storage.(DirectUploader)
+	if !ok {
+		return nil, ErrDirectUploadUnsupported
+	}
+
+	mimeType, _, _ := strings.Cut(strings.ToLower(contentType), ";")
+	if mimeType = strings.TrimSpace(mimeType); mimeType == "image/jpg" {
+		mimeType = "image/jpeg"
+	}
+	format, ok := imageFormats[formatOf(mimeType)]
+	if !ok {
+		return nil, &ImageValidationError{ImageErrUnsupportedType, fmt.Sprintf("content type %q is not a supported image (jpeg, png, gif, webp)", contentType)}
+	}
+	if size > s.images.MaxBytes() {
+		return nil, &ImageValidationError{ImageErrTooLarge, fmt.Sprintf("image exceeds the %d MB limit", s.images.MaxBytes()>>20)}
+	}
+
+	id := uuid.New()
+	claims := &DirectUploadClaims{
+		ID:          id,
+		Key:         DirectUploadPrefix + id.String() + format.ext,
+		Filename:    path.Base(strings.ReplaceAll(filename, `\`, "/")),
+		ContentType: format.mimeType,
+		UploadedBy:  uploadedBy,
+		ExpiresAt:   time.Now().Add(s.ttl).Truncate(time.Second),
+	}
+
+	upload, err := uploader.PresignUpload(ctx, claims.Key, claims.ContentType, s.images.MaxBytes(), claims.ExpiresAt)
+	if err != nil {
+		return nil, err
+	}
+	token, err := s.sign(claims)
+	if err != nil {
+		return nil, err
+	}
+
+	return &DirectUploadTicket{
+		ID:        id,
+		Key:       claims.Key,
+		Token:     token,
+		Upload:    upload,
+		ExpiresAt: upload.ExpiresAt,
+	}, nil
+}
+
+// Verify checks an upload token and returns its claims.
+func (s *DirectUploadService) Verify(token string) (*DirectUploadClaims, error) {
+	payload, signature, ok := strings.Cut(token, ".")
+	if !ok {
+		return nil, ErrUploadTokenInvalid
+	}
+	sig, err := base64.RawURLEncoding.DecodeString(signature)
+	if err != nil || !hmac.Equal(sig, s.mac(payload)) {
+		return nil, ErrUploadTokenInvalid
+	}
+
+	raw, err := base64.RawURLEncoding.DecodeString(payload)
+	if err != nil {
+		return nil, ErrUploadTokenInvalid
+	}
+	var claims DirectUploadClaims
+	if err := json.Unmarshal(raw, &claims); err != nil {
+		return nil, ErrUploadTokenInvalid
+	}
+	if time.Now().After(claims.ExpiresAt.Add(directUploadGrace)) {
+		return nil, ErrUploadTokenExpired
+	}
+	return &claims, nil
+}
+
+func (s *DirectUploadService) sign(claims *DirectUploadClaims) (string, error) {
+	raw, err := json.Marshal(claims)
+	if err != nil {
+		return "", err
+	}
+	payload := base64.RawURLEncoding.EncodeToString(raw)
+	return payload + "." + base64.RawURLEncoding.EncodeToString(s.mac(payload)), nil
+}
+
+func (s *DirectUploadService) mac(payload string) []byte {
+	mac := hmac.New(sha256.New, s.secret)
+	mac.Write([]byte(payload))
+	return mac.Sum(nil)
+}
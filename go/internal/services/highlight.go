@@ -0,0 +1,162 @@
+package services
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/alecthomas/chroma/v2"
+	chromahtml "github.com/alecthomas/chroma/v2/formatters/html"
+	"github.com/alecthomas/chroma/v2/lexers"
+	"github.com/alecthomas/chroma/v2/styles"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/renderer"
+	"github.com/yuin/goldmark/util"
+)
+
+var highlightThemes = map[string]string{
+	"light": "github",
+	"dark":  "github-dark",
+}
+
+var highlightRangePattern = regexp.MustCompile(`\{([\d,\s-]+)\}`)
+
+// codeBlockRenderer replaces goldmark's fenced code output with chroma's
+// class-based markup. The info string accepts a language followed by
+// optional annotations, e.g. "go {3,5-7} nolinenos".
+type codeBlockRenderer struct{}
+
+func (r *codeBlockRenderer) RegisterFuncs(reg renderer.NodeRendererFuncRegisterer) {
+	reg.Register(ast.KindFencedCodeBlock, r.renderFencedCodeBlock)
+}
+
+func (r *codeBlockRenderer) renderFencedCodeBlock(w util.BufWriter, source []byte, node ast.Node, entering bool) (ast.WalkStatus, error) {
+	if !entering {
+		return ast.WalkContinue, nil
+	}
+	n := node.(*ast.FencedCodeBlock)
+
+	var code bytes.Buffer
+	lines := n.Lines()
+	for i := 0; i < lines.Len(); i++ {
+		segment := lines.At(i)
+		code.Write(segment.Value(source))
+	}
+
+	info := ""
+	if n.Info != nil {
+		info = string(n.Info.Segment.Value(source))
+	}
+	language, lineNumbers, highlighted := parseCodeInfo(info)
+
+	lexer := lexers.Get(language)
+	if lexer == nil {
+		lexer = lexers.Fallback
+	}
+	lexer = chroma.Coalesce(lexer)
+
+	iterator, err := lexer.Tokenise(nil, code.String())
+	if err != nil {
+		return ast.WalkStop, fmt.Errorf("failed to tokenise code block: %w", err)
+	}
+
+	formatter := chromahtml.New(
+		chromahtml.WithClasses(true),
+		chromahtml.WithLineNumbers(lineNumbers),
+		chromahtml.LineNumbersInTable(true),
+		chromahtml.HighlightLines(highlighted),
+		chromahtml.TabWidth(4),
+	)
+
+	if language != "" {
+		fmt.Fprintf(w, `<div class="code-block language-%s">`, sanitizeLanguage(language))
+	} else {
+		w.WriteString(`<div class="code-block">`)
+	}
+	if err := formatter.Format(w, styles.Fallback, iterator); err != nil {
+		return ast.WalkStop, fmt.Errorf("failed to highlight code block: %w", err)
+	}
+	w.WriteString("</div>\n")
+	return ast.WalkSkipChildren, nil
+}
+
+func parseCodeInfo(info string) (string, bool, [][2]int) {
+	var ranges [][2]int
+	if match := highlightRangePattern.FindStringSubmatch(info); match != nil {
+		info = strings.Replace(info, match[0], " ", 1)
+		for _, part := range strings.Split(match[1], ",") {
+			part = strings.TrimSpace(part)
+			bounds := strings.SplitN(part, "-", 2)
+			start, err := strconv.Atoi(strings.TrimSpace(bounds[0]))
+			if err != nil {
+				continue
+			}
+			end := start
+			if len(bounds) == 2 {
+				if parsed, err := strconv.Atoi(strings.TrimSpace(bounds[1])); err == nil && parsed >= start {
+					end = parsed
+				}
+			}
+			ranges = append(ranges, [2]int{start, end})
+		}
+	}
+
+	fields := strings.Fields(info)
+	language := ""
+	lineNumbers := true
+	for i, field := range fields {
+		switch {
+		case field == "nolinenos":
+			lineNumbers = false
+		case field == "linenos":
+			lineNumbers = true
+		case i == 0:
+			language = strings.ToLower(field)
+		}
+	}
+	return language, lineNumbers, ranges
+}
+
+// sanitizeLanguage turns a language name into a class name the sanitizer
+// keeps: c++ becomes cpp and c# csharp, anything else outside [a-z0-9-] is
+// dropped.
+func sanitizeLanguage(language string) string {
+	language = strings.NewReplacer("+", "p", "#", "sharp").Replace(language)
+	return strings.Map(func(r rune) rune {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') || r == '-' {
+			return r
+		}
+		return -1
+	}, language)
+}
+
+// HighlightCSS returns the stylesheet for a theme ("light", "dark" or
+// "auto", which switches on prefers-color-scheme).
+func HighlightCSS(theme string) (string, error) {
+	formatter := chromahtml.New(chromahtml.WithClasses(true), chromahtml.WithLineNumbers(true), chromahtml.LineNumbersInTable(true))
+
+	if theme == "auto" {
+		light, err := HighlightCSS("light")
+		if err != nil {
+			return "", err
+		}
+		dark, err := HighlightCSS("dark")
+		if err != nil {
+			return "", err
+		}
+		return light + "\n@media (prefers-color-scheme: dark) {\n" + dark + "}\n", nil
+	}
+
+	name, ok := highlightThemes[theme]
+	if !ok {
+		return "", fmt.Errorf("unknown theme %q", theme)
+	}
+
+	var buf bytes.Buffer
+	if err := formatter.WriteCSS(&buf, styles.Get(name)); err != nil {
+		return "", fmt.Errorf("failed to generate stylesheet: %w", err)
+	}
+	return buf.String(), nil
+}
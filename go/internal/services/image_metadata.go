@@ -0,0 +1,430 @@
+package services
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"image"
+	"image/draw"
+	"io"
+)
+
+var errTruncated = errors.New("truncated data")
+
+// span is one piece of a prepared image: a range of the uploaded file or,
+// when data is set, bytes that stand in for one.
+type span struct {
+	off, n int64
+	data   []byte
+}
+
+// imagePlan lists the pieces an image is rebuilt from once its metadata is
+// left out, so it can be streamed without being copied into memory.
+type imagePlan []span
+
+func (p *imagePlan) keep(off, n int64) {
+	if n <= 0 {
+		return
+	}
+	if last := len(*p) - 1; last >= 0 && (*p)[last].data == nil && (*p)[last].off+(*p)[last].n == off {
+		(*p)[last].n += n
+		return
+	}
+	*p = append(*p, span{off: off, n: n})
+}
+
+func (p *imagePlan) insert(data []byte) {
+	*p = append(*p, span{n: int64(len(data)), data: data})
+}
+
+func (p imagePlan) size() int64 {
+	var size int64
+	for _, s := range p {
+		size += s.n
+	}
+	return size
+}
+
+func (p imagePlan) reader(src io.ReaderAt) io.Reader {
+	readers := make([]io.Reader, 0, len(p))
+	for _, s := range p {
+		if s.data != nil {
+			readers = append(readers, bytes.NewReader(s.data))
+		} else {
+			readers = append(readers, io.NewSectionReader(src, s.off, s.n))
+		}
+	}
+	return io.MultiReader(readers...)
+}
+
+// imageScanner walks a file front to back, reading the headers it is asked
+// for and skipping everything else.
+type imageScanner struct {
+	r   *bufio.Reader
+	pos int64
+}
+
+func newImageScanner(src io.ReaderAt, size int64) *imageScanner {
+	return &imageScanner{r: bufio.NewReader(io.NewSectionReader(src, 0, size))}
+}
+
+func (s *imageScanner) peek(n int) ([]byte, error) {
+	buf, err := s.r.Peek(n)
+	if err != nil {
+		return nil, errTruncated
+	}
+	return buf, nil
+}
+
+func (s *imageScanner) read(n int) ([]byte, error) {
+	buf := make([]byte, n)
+	if _, err := io.ReadFull(s.r, buf); err != nil {
+		return nil, errTruncated
+	}
+	s.pos += int64(n)
+	return buf, nil
+}
+
+func (s *imageScanner) skip(n int64) error {
+	if _, err := io.CopyN(io.Discard, s.r, n); err != nil {
+		return errTruncated
+	}
+	s.pos += n
+	return nil
+}
+
+// planMetadata works out how to drop EXIF, XMP, IPTC and comments without
+// re-encoding pixel data. For JPEGs it also returns the EXIF orientation
+// that was dropped, so the caller can apply it.
+func planMetadata(format string, src io.ReaderAt, size int64) (imagePlan, int, error) {
+	switch format {
+	case "jpeg":
+		return planJPEG(src, size)
+	case "png":
+		plan, err := planPNG(src, size)
+		return plan, 1, err
+	case "webp":
+		plan, err := planWebP(src, size)
+		return plan, 1, err
+	case "gif":
+		plan, err := planGIF(src, size)
+		return plan, 1, err
+	}
+	var plan imagePlan
+	plan.keep(0, size)
+	return plan, 1, nil
+}
+
+func planJPEG(src io.ReaderAt, size int64) (imagePlan, int, error) {
+	s := newImageScanner(src, size)
+	soi, err := s.read(2)
+	if err != nil || size < 4 || soi[0] != 0xFF || soi[1] != 0xD8 {
+		return nil, 0, errors.New("missing JPEG start marker")
+	}
+
+	var plan imagePlan
+	plan.keep(0, 2)
+	orientation := 1
+
+	for s.pos < size {
+		m, err := s.peek(2)
+		if err != nil {
+			return nil, 0, err
+		}
+		if m[0] != 0xFF {
+			return nil, 0, errors.New("invalid JPEG marker")
+		}
+		marker := m[1]
+		if marker == 0xFF {
+			s.skip(1)
+			continue
+		}
+		// Entropy-coded data follows the start of scan; keep the rest as is.
+		if marker == 0xDA || marker == 0xD9 {
+			plan.keep(s.pos, size-s.pos)
+			return plan, orientation, nil
+		}
+		header, err := s.peek(4)
+		if err != nil {
+			return nil, 0, err
+		}
+		length := int64(binary.BigEndian.Uint16(header[2:]))
+		start, end := s.pos, s.pos+2+length
+		if length < 2 || end > size {
+			return nil, 0, errTruncated
+		}
+
+		switch {
+		case marker == 0xE1:
+			// APP1 segments are at most 64 KB, so reading one is cheap.
+			segment, err := s.read(int(end - start))
+			if err != nil {
+				return nil, 0, err
+			}
+			if bytes.HasPrefix(segment[4:], []byte("Exif\x00\x00")) {
+				if o := exifOrientation(segment[10:]); o > 0 {
+					orientation = o
+				}
+			}
+			continue
+		case marker == 0xED, marker == 0xFE:
+			// IPTC/Photoshop resources and comments.
+		default:
+			plan.keep(start, end-start)
+		}
+		if err := s.skip(end - start); err != nil {
+			return nil, 0, err
+		}
+	}
+	return nil, 0, errTruncated
+}
+
+// exifOrientation reads tag 0x0112 from IFD0 of a TIFF-structured EXIF
+// block.
+func exifOrientation(tiff []byte) int {
+	if len(tiff) < 8 {
+		return 0
+	}
+	var order binary.ByteOrder
+	switch string(tiff[:2]) {
+	case "II":
+		order = binary.LittleEndian
+	case "MM":
+		order = binary.BigEndian
+	default:
+		return 0
+	}
+
+	offset := int(order.Uint32(tiff[4:]))
+	if offset+2 > len(tiff) {
+		return 0
+	}
+	count := int(order.Uint16(tiff[offset:]))
+	for n := 0; n < count; n++ {
+		entry := offset + 2 + n*12
+		if entry+12 > len(tiff) {
+			return 0
+		}
+		if order.Uint16(tiff[entry:]) == 0x0112 {
+			return int(order.Uint16(tiff[entry+8:]))
+		}
+	}
+	return 0
+}
+
+var pngMetadataChunks = map[string]bool{
+	"eXIf": true, "tEXt": true, "zTXt": true, "iTXt": true, "tIME": true,
+}
+
+func planPNG(src io.ReaderAt, size int64) (imagePlan, error) {
+	s := newImageScanner(src, size)
+	if err := s.skip(8); err != nil {
+		return nil, err
+	}
+	var plan imagePlan
+	plan.keep(0, 8)
+
+	for s.pos < size {
+		start := s.pos
+		if start+12 > size {
+			return nil, errTruncated
+		}
+		header, err := s.read(8)
+		if err != nil {
+			return nil, err
+		}
+		end := start + 12 + int64(binary.BigEndian.Uint32(header))
+		if end > size {
+			return nil, errTruncated
+		}
+		chunkType := string(header[4:8])
+		if !pngMetadataChunks[chunkType] {
+			plan.keep(start, end-start)
+		}
+		if err := s.skip(end - s.pos); err != nil {
+			return nil, err
+		}
+		if chunkType == "IEND" {
+			return plan, nil
+		}
+	}
+	return nil, errTruncated
+}
+
+func planWebP(src io.ReaderAt, size int64) (imagePlan, error) {
+	s := newImageScanner(src, size)
+	header, err := s.read(12)
+	if err != nil {
+		return nil, err
+	}
+	var plan imagePlan
+	plan.insert(header)
+
+	for s.pos < size {
+		start := s.pos
+		if start+8 > size {
+			return nil, errTruncated
+		}
+		chunk, err := s.read(8)
+		if err != nil {
+			return nil, err
+		}
+		fourCC := string(chunk[:4])
+		n := int64(binary.LittleEndian.Uint32(chunk[4:]))
+		end := start + 8 + n + n&1
+		if end > size {
+			if start+8+n != size {
+				return nil, errTruncated
+			}
+			end = size
+		}
+		switch {
+		case fourCC == "EXIF", fourCC == "XMP ":
+		case fourCC == "VP8X" && end-start > 8:
+			flags, err := s.read(1)
+			if err != nil {
+				return nil, err
+			}
+			// Clear the EXIF and XMP presence flags.
+			flags[0] &^= 0x08 | 0x04
+			plan.insert(append(chunk, flags...))
+			plan.keep(start+9, end-start-9)
+		default:
+			plan.keep(start, end-start)
+		}
+		if err := s.skip(end - s.pos); err != nil {
+			return nil, err
+		}
+	}
+
+	binary.LittleEndian.PutUint32(header[4:], uint32(plan.size()-8))
+	return plan, nil
+}
+
+func planGIF(src io.ReaderAt, size int64) (imagePlan, error) {
+	s := newImageScanner(src, size)
+	screen, err := s.read(13)
+	if err != nil {
+		return nil, err
+	}
+	if screen[10]&0x80 != 0 {
+		if err := s.skip(3 << ((screen[10] & 0x07) + 1)); err != nil {
+			return nil, err
+		}
+	}
+	var plan imagePlan
+	plan.keep(0, s.pos)
+
+	// skipSubBlocks moves just past a run of data sub-blocks.
+	skipSubBlocks := func() error {
+		for {
+			n, err := s.read(1)
+			if err != nil {
+				return err
+			}
+			if n[0] == 0 {
+				return nil
+			}
+			if err := s.skip(int64(n[0])); err != nil {
+				return err
+			}
+		}
+	}
+
+	for s.pos < size {
+		start := s.pos
+		introducer, err := s.peek(1)
+		if err != nil {
+			return nil, err
+		}
+		switch introducer[0] {
+		case 0x3B:
+			plan.keep(start, 1)
+			return plan, nil
+		case 0x21:
+			ext, err := s.read(2)
+			if err != nil {
+				return nil, err
+			}
+			label := ext[1]
+			keep := label != 0xFE
+			if label == 0xFF {
+				// Keep only the looping extensions animated GIFs rely on.
+				app, err := s.peek(12)
+				keep = err == nil && (string(app[1:]) == "NETSCAPE2.0" || string(app[1:]) == "ANIMEXTS1.0")
+			}
+			if err := skipSubBlocks(); err != nil {
+				return nil, err
+			}
+			if keep {
+				plan.keep(start, s.pos-start)
+			}
+		case 0x2C:
+			descriptor, err := s.read(10)
+			if err != nil {
+				return nil, err
+			}
+			packed := descriptor[9]
+			if packed&0x80 != 0 {
+				if err := s.skip(3 << ((packed & 0x07) + 1)); err != nil {
+					return nil, err
+				}
+			}
+			// LZW minimum code size.
+			if err := s.skip(1); err != nil {
+				return nil, err
+			}
+			if err := skipSubBlocks(); err != nil {
+				return nil, err
+			}
+			plan.keep(start, s.pos-start)
+		default:
+			return nil, errors.New("invalid GIF block")
+		}
+	}
+	return nil, errTruncated
+}
+
+// applyOrientation turns an image as described by EXIF orientation values
+// 2 to 8.
+func applyOrientation(img image.Image, orientation int) image.Image {
+	b := img.Bounds()
+	src := image.NewRGBA(image.Rect(0, 0, b.Dx(), b.Dy()))
+	draw.Draw(src, src.Bounds(), img, b.Min, draw.Src)
+	w, h := b.Dx(), b.Dy()
+
+	dw, dh := w, h
+	if orientation >= 5 {
+		dw, dh = h, w
+	}
+	dst := image.NewRGBA(image.Rect(0, 0, dw, dh))
+
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			var dx, dy int
+			switch orientation {
+			case 2:
+				dx, dy = w-1-x, y
+			case 3:
+				dx, dy = w-1-x, h-1-y
+			case 4:
+				dx, dy = x, h-1-y
+			case 5:
+				dx, dy = y, x
+			case 6:
+				dx, dy = h-1-y, x
+			case 7:
+				dx, dy = h-1-y, w-1-x
+			case 8:
+				dx, dy = y, w-1-x
+			default:
+				dx, dy = x, y
+			}
+			si := src.PixOffset(x, y)
+			di := dst.PixOffset(dx, dy)
+			copy(dst.Pix[di:di+4], src.Pix[si:si+4])
+		}
+	}
+	return dst
+}
@@ -0,0 +1,251 @@
+package services
+
+import (
+	"bufio"
+	"fmt"
+	"image"
+	_ "image/gif"
+	"image/jpeg"
+	_ "image/png"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+
+	_ "golang.org/x/image/webp"
+)
+
+// Image validation error codes, returned to clients alongside the message.
+const (
+	ImageErrUnsupportedType = "unsupported_type"
+	ImageErrCorrupt         = "corrupt_image"
+	ImageErrTooLarge        = "file_too_large"
+	ImageErrDimensions      = "dimensions_too_large"
+)
+
+type ImageValidationError struct {
+	Code    string
+	Message string
+}
+
+func (e *ImageValidationError) Error() string {
+	return e.Message
+}
+
+type imageFormat struct {
+	mimeType string
+	ext      string
+}
+
+// Only formats that cannot carry script are accepted; SVG is deliberately
+// absent.
+var imageFormats = map[string]imageFormat{
+	"jpeg": {"image/jpeg", ".jpg"},
+	"png":  {"image/png", ".png"},
+	"gif":  {"image/gif", ".gif"},
+	"webp": {"image/webp", ".webp"},
+}
+
+// ImageFile is an upload that can be read at any offset, such as a
+// multipart file or a temporary file.
+type ImageFile interface {
+	io.ReaderAt
+	io.Closer
+}
+
+// PreparedImage is an upload that passed validation. Its bytes stay in the
+// file it came from; Open streams them with the metadata left out.
+type PreparedImage struct {
+	Format          string
+	MimeType        string
+	Ext             string
+	Width           int
+	Height          int
+	Size            int64
+	MetadataRemoved bool
+
+	src  ImageFile
+	plan imagePlan
+}
+
+// Open returns a new reader over the cleaned image.
+func (p *PreparedImage) Open() io.Reader {
+	return p.plan.reader(p.src)
+}
+
+// Close releases the file behind the image.
+func (p *PreparedImage) Close() error {
+	return p.src.Close()
+}
+
+// tempFile is removed from disk when it is closed.
+type tempFile struct {
+	*os.File
+}
+
+func (f tempFile) Close() error {
+	err := f.File.Close()
+	os.Remove(f.Name())
+	return err
+}
+
+type ImageService struct {
+	maxBytes  int64
+	maxWidth  int
+	maxHeight int
+	maxPixels int
+}
+
+func NewImageService() *ImageService {
+	return &ImageService{
+		maxBytes:  int64(envInt("IMAGE_MAX_BYTES", 10*1024*1024)),
+		maxWidth:  envInt("IMAGE_MAX_WIDTH", 8000),
+		maxHeight: envInt("IMAGE_MAX_HEIGHT", 8000),
+		maxPixels: envInt("IMAGE_MAX_PIXELS", 40_000_000),
+	}
+}
+
+func (s *ImageService) MaxBytes() int64 {
+	return s.maxBytes
+}
+
+// Prepare is PrepareFile for an image that arrives as a stream, e.g. from
+// storage. The stream is spooled to a temporary file, up to maxBytes, rather
+// than read into memory.
+func (s *ImageService) Prepare(r io.Reader) (*PreparedImage, error) {
+	file, err := os.CreateTemp("", "image-*")
+	if err != nil {
+		return nil, fmt.Errorf("failed to buffer image: %w", err)
+	}
+	size, err := io.Copy(file, io.LimitReader(r, s.maxBytes+1))
+	if err != nil {
+		tempFile{file}.Close()
+		return nil, fmt.Errorf("failed to read image: %w", err)
+	}
+	return s.PrepareFile(tempFile{file}, size)
+}
+
+// PrepareFile decides what an upload is from its bytes, never its name. The
+// header is decoded to check format and dimensions before any pixel data
+// is touched, so oversized images are refused without being decompressed.
+// Metadata is stripped by leaving it out when the image is read back, so
+// the file is never copied into memory. The prepared image takes over src
+// and closes it along with itself; src is closed straight away when the
+// image is refused.
+func (s *ImageService) PrepareFile(src ImageFile, size int64) (*PreparedImage, error) {
+	prepared, err := s.prepare(src, size)
+	if err != nil {
+		src.Close()
+		return nil, err
+	}
+	return prepared, nil
+}
+
+func (s *ImageService) prepare(src ImageFile, size int64) (*PreparedImage, error) {
+	if size > s.maxBytes {
+		return nil, &ImageValidationError{ImageErrTooLarge, fmt.Sprintf("image exceeds the %d MB limit", s.maxBytes>>20)}
+	}
+	if size == 0 {
+		return nil, &ImageValidationError{ImageErrCorrupt, "image file is empty"}
+	}
+
+	head := make([]byte, min(size, 512))
+	if _, err := src.ReadAt(head, 0); err != nil && err != io.EOF {
+		return nil, fmt.Errorf("failed to read image: %w", err)
+	}
+	sniffed, _, _ := strings.Cut(http.DetectContentType(head), ";")
+	cfg, format, err := image.DecodeConfig(io.NewSectionReader(src, 0, size))
+	if err != nil {
+		if _, known := imageFormats[formatOf(sniffed)]; known {
+			return nil, &ImageValidationError{ImageErrCorrupt, fmt.Sprintf("file looks like %s but its header could not be decoded", sniffed)}
+		}
+		return nil, &ImageValidationError{ImageErrUnsupportedType, fmt.Sprintf("file content is %s, not a supported image (jpeg, png, gif, webp)", sniffed)}
+	}
+	known, ok := imageFormats[format]
+	if !ok {
+		return nil, &ImageValidationError{ImageErrUnsupportedType, fmt.Sprintf("image format %s is not supported (jpeg, png, gif, webp)", format)}
+	}
+	if known.mimeType != sniffed {
+		return nil, &ImageValidationError{ImageErrCorrupt, fmt.Sprintf("file decodes as %s but its signature reads as %s", format, sniffed)}
+	}
+
+	if cfg.Width <= 0 || cfg.Height <= 0 {
+		return nil, &ImageValidationError{ImageErrCorrupt, "image has no pixels"}
+	}
+	if cfg.Width > s.maxWidth || cfg.Height > s.maxHeight {
+		return nil, &ImageValidationError{ImageErrDimensions, fmt.Sprintf("image is %dx%d pixels; the maximum is %dx%d", cfg.Width, cfg.Height, s.maxWidth, s.maxHeight)}
+	}
+	if cfg.Width*cfg.Height > s.maxPixels {
+		return nil, &ImageValidationError{ImageErrDimensions, fmt.Sprintf("image has %d pixels; the maximum is %d", cfg.Width*cfg.Height, s.maxPixels)}
+	}
+
+	plan, orientation, err := planMetadata(format, src, size)
+	if err != nil {
+		return nil, &ImageValidationError{ImageErrCorrupt, fmt.Sprintf("image is malformed: %v", err)}
+	}
+	prepared := &PreparedImage{
+		Format:          format,
+		MimeType:        known.mimeType,
+		Ext:             known.ext,
+		Width:           cfg.Width,
+		Height:          cfg.Height,
+		Size:            plan.size(),
+		MetadataRemoved: plan.size() != size,
+		src:             src,
+		plan:            plan,
+	}
+
+	// The EXIF orientation tag is gone now, so bake it into the pixels.
+	if orientation > 1 && orientation <= 8 {
+		if err := s.orient(prepared, orientation); err != nil {
+			return nil, err
+		}
+	}
+	return prepared, nil
+}
+
+// orient re-encodes a JPEG with its orientation applied to the pixels. The
+// result goes to a temporary file, which replaces the upload as the source.
+func (s *ImageService) orient(prepared *PreparedImage, orientation int) error {
+	img, err := jpeg.Decode(prepared.Open())
+	if err != nil {
+		return &ImageValidationError{ImageErrCorrupt, fmt.Sprintf("image could not be decoded: %v", err)}
+	}
+	oriented := applyOrientation(img, orientation)
+
+	file, err := os.CreateTemp("", "image-*")
+	if err != nil {
+		return fmt.Errorf("failed to buffer image: %w", err)
+	}
+	out := bufio.NewWriter(file)
+	if err := jpeg.Encode(out, oriented, &jpeg.Options{Quality: 90}); err != nil {
+		tempFile{file}.Close()
+		return fmt.Errorf("failed to encode image: %w", err)
+	}
+	if err := out.Flush(); err != nil {
+		tempFile{file}.Close()
+		return fmt.Errorf("failed to encode image: %w", err)
+	}
+	info, err := file.Stat()
+	if err != nil {
+		tempFile{file}.Close()
+		return fmt.Errorf("failed to encode image: %w", err)
+	}
+
+	prepared.src.Close()
+	prepared.src = tempFile{file}
+	prepared.plan = nil
+	prepared.plan.keep(0, info.Size())
+	prepared.Size = info.Size()
+	prepared.Width, prepared.Height = oriented.Bounds().Dx(), oriented.Bounds().Dy()
+	return nil
+}
+
+func formatOf(mimeType string) string {
+	for format, known := range imageFormats {
+		if known.mimeType == mimeType {
+			return format
+		}
+	}
+	return ""
+}
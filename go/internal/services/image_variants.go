@@ -0,0 +1,115 @@
+package services
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+
+	"github.com/chai2010/webp"
+	"golang.org/x/image/draw"
+)
+
+type imageVariantSpec struct {
+	name  string
+	width int
+}
+
+var imageVariantSpecs = []imageVariantSpec{
+	{"thumbnail", 320},
+	{"card", 768},
+	{"full", 1600},
+}
+
+type EncodedVariant struct {
+	Name     string
+	Width    int
+	Height   int
+	MimeType string
+	Ext      string
+	Data     []byte
+}
+
+// Variants renders the thumbnail, card and full sizes of an image, each as
+// JPEG (PNG when the image has transparency) plus a WebP copy. Images are
+// never upscaled, so a small image may yield fewer sizes. WebP copies are
+// lossy at a quality comparable to the JPEG; one that still comes out larger
+// than its fallback is dropped, since serving it would defeat the point.
+// Animated GIFs get no variants because resizing would flatten them.
+func (s *ImageService) Variants(img *PreparedImage) ([]*EncodedVariant, error) {
+	if img.Format == "gif" {
+		anim, err := gif.DecodeAll(img.Open())
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode image: %w", err)
+		}
+		if len(anim.Image) > 1 {
+			return nil, nil
+		}
+	}
+
+	src, _, err := image.Decode(img.Open())
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode image: %w", err)
+	}
+	opaque := isOpaque(src)
+
+	var variants []*EncodedVariant
+	lastWidth := 0
+	for _, spec := range imageVariantSpecs {
+		width := min(spec.width, img.Width)
+		if width == lastWidth {
+			continue
+		}
+		lastWidth = width
+		height := max(1, (img.Height*width+img.Width/2)/img.Width)
+
+		var resized image.Image = src
+		if width != img.Width {
+			dst := image.NewRGBA(image.Rect(0, 0, width, height))
+			draw.CatmullRom.Scale(dst, dst.Bounds(), src, src.Bounds(), draw.Src, nil)
+			resized = dst
+		}
+
+		fallback := &EncodedVariant{Name: spec.name, Width: width, Height: height}
+		var buf bytes.Buffer
+		if opaque {
+			fallback.MimeType, fallback.Ext = "image/jpeg", ".jpg"
+			err = jpeg.Encode(&buf, resized, &jpeg.Options{Quality: 82})
+		} else {
+			fallback.MimeType, fallback.Ext = "image/png", ".png"
+			err = (&png.Encoder{CompressionLevel: png.BestCompression}).Encode(&buf, resized)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode %s variant: %w", spec.name, err)
+		}
+		fallback.Data = buf.Bytes()
+		variants = append(variants, fallback)
+
+		var webpBuf bytes.Buffer
+		if err := webp.Encode(&webpBuf, resized, &webp.Options{Quality: 80}); err != nil {
+			return nil, fmt.Errorf("failed to encode %s WebP variant: %w", spec.name, err)
+		}
+		// The format is part of the name: some backends and the reconcile
+		// job ignore extensions, so "card.webp" would collide with "card.jpg".
+		if webpBuf.Len() < len(fallback.Data) {
+			variants = append(variants, &EncodedVariant{
+				Name:     spec.name + "-webp",
+				Width:    width,
+				Height:   height,
+				MimeType: "image/webp",
+				Ext:      ".webp",
+				Data:     webpBuf.Bytes(),
+			})
+		}
+	}
+	return variants, nil
+}
+
+func isOpaque(img image.Image) bool {
+	if o, ok := img.(interface{ Opaque() bool }); ok {
+		return o.Opaque()
+	}
+	return false
+}
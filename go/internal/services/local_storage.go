@@ -0,0 +1,299 @@
+package services
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// LocalMediaPath is where the API serves files kept by LocalStorage.
+	LocalMediaPath = "/media"
+	// LocalUploadPath is where the API accepts direct uploads for LocalStorage.
+	LocalUploadPath = "/api/v1/uploads"
+)
+
+type LocalStorage struct {
+	dir          string
+	baseURL      string
+	uploadSecret []byte
+}
+
+func NewLocalStorage() (*LocalStorage, error) {
+	dir := os.Getenv("STORAGE_LOCAL_DIR")
+	if dir == "" {
+		dir = "./uploads"
+	}
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, fmt.Errorf("invalid STORAGE_LOCAL_DIR: %w", err)
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create storage directory: %w", err)
+	}
+
+	baseURL := strings.TrimRight(os.Getenv("STORAGE_PUBLIC_URL"), "/")
+	if baseURL == "" {
+		baseURL = LocalMediaPath
+	}
+
+	secret, err := uploadSecret()
+	if err != nil {
+		return nil, err
+	}
+
+	return &LocalStorage{dir: dir, baseURL: baseURL, uploadSecret: secret}, nil
+}
+
+func (s *LocalStorage) Name() string {
+	return "local"
+}
+
+func (s *LocalStorage) Dir() string {
+	return s.dir
+}
+
+// PublicFS is what LocalMediaPath serves: the storage directory without
+// directory listings and without staged direct uploads, which have not been
+// validated or stripped of metadata yet.
+func (s *LocalStorage) PublicFS() http.FileSystem {
+	return publicDir{http.Dir(s.dir)}
+}
+
+type publicDir struct {
+	http.FileSystem
+}
+
+func (d publicDir) Open(name string) (http.File, error) {
+	key := strings.TrimPrefix(path.Clean("/"+name), "/")
+	if strings.HasPrefix(key+"/", DirectUploadPrefix) {
+		return nil, os.ErrNotExist
+	}
+
+	f, err := d.FileSystem.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	if info, err := f.Stat(); err != nil || info.IsDir() {
+		f.Close()
+		return nil, os.ErrNotExist
+	}
+	return f, nil
+}
+
+func (s *LocalStorage) path(key string) (string, error) {
+	key, err := cleanKey(key)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(s.dir, filepath.FromSlash(key)), nil
+}
+
+// Put writes to a temporary file first so readers never see a partial
+// upload under the final name.
+func (s *LocalStorage) Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) (*StoredObject, error) {
+	target, err := s.path(key)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create storage directory: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(target), ".upload-*")
+	if err != nil {
+		return nil, fmt.Errorf("failed to store file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	written, err := io.Copy(tmp, r)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to store file: %w", err)
+	}
+	if size >= 0 && written != size {
+		return nil, fmt.Errorf("failed to store file: wrote %d of %d bytes", written, size)
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if err := os.Chmod(tmp.Name(), 0o644); err != nil {
+		return nil, fmt.Errorf("failed to store file: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), target); err != nil {
+		return nil, fmt.Errorf("failed to store file: %w", err)
+	}
+
+	return s.Stat(ctx, key)
+}
+
+func (s *LocalStorage) Delete(ctx context.Context, key string) error {
+	target, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(target); err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return ErrObjectNotFound
+		}
+		return fmt.Errorf("failed to delete file: %w", err)
+	}
+	return nil
+}
+
+func (s *LocalStorage) Open(ctx context.Context, key string) (io.ReadCloser, error) {
+	target, err := s.path(key)
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.Open(target)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, ErrObjectNotFound
+		}
+		return nil, fmt.Errorf("failed to open file: %w", err)
+	}
+	return f, nil
+}
+
+// PresignUpload points the client at the API's own upload endpoint, with
+// the limits signed into the URL. The upload is streamed to disk there.
+func (s *LocalStorage) PresignUpload(ctx context.Context, key, contentType string, maxBytes int64, expiresAt time.Time) (*DirectUpload, error) {
+	if _, err := s.path(key); err != nil {
+		return nil, err
+	}
+
+	query := url.Values{
+		"type":    {contentType},
+		"max":     {strconv.FormatInt(maxBytes, 10)},
+		"expires": {strconv.FormatInt(expiresAt.Unix(), 10)},
+	}
+	query.Set("signature", s.uploadSignature(key, query))
+	return &DirectUpload{
+		Method:    http.MethodPut,
+		URL:       LocalUploadPath + "/" + key + "?" + query.Encode(),
+		Headers:   map[string]string{"Content-Type": contentType},
+		ExpiresAt: expiresAt,
+	}, nil
+}
+
+// VerifyUpload checks a signed upload URL from PresignUpload against the
+// request it arrived with, and returns the maximum size it allows.
+func (s *LocalStorage) VerifyUpload(key string, query url.Values, contentType string) (int64, error) {
+	signature, err := hex.DecodeString(query.Get("signature"))
+	if err != nil {
+		return 0, ErrUploadTokenInvalid
+	}
+	expected, _ := hex.DecodeString(s.uploadSignature(key, query))
+	if !hmac.Equal(signature, expected) {
+		return 0, ErrUploadTokenInvalid
+	}
+
+	expires, err := strconv.ParseInt(query.Get("expires"), 10, 64)
+	if err != nil {
+		return 0, ErrUploadTokenInvalid
+	}
+	if time.Now().After(time.Unix(expires, 0)) {
+		return 0, ErrUploadTokenExpired
+	}
+	if mimeType, _, _ := strings.Cut(contentType, ";"); !strings.EqualFold(strings.TrimSpace(mimeType), query.Get("type")) {
+		return 0, fmt.Errorf("content type must be %s", query.Get("type"))
+	}
+	return strconv.ParseInt(query.Get("max"), 10, 64)
+}
+
+func (s *LocalStorage) uploadSignature(key string, query url.Values) string {
+	mac := hmac.New(sha256.New, s.uploadSecret)
+	for _, part := range []string{key, query.Get("type"), query.Get("max"), query.Get("expires")} {
+		mac.Write([]byte(part))
+		mac.Write([]byte{0})
+	}
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func (s *LocalStorage) URL(key string) string {
+	return s.baseURL + "/" + key
+}
+
+func (s *LocalStorage) List(ctx context.Context, prefix string) ([]*StoredObject, error) {
+	var objects []*StoredObject
+	err := filepath.WalkDir(s.dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if d.IsDir() || strings.HasPrefix(d.Name(), ".upload-") {
+			return nil
+		}
+
+		rel, err := filepath.Rel(s.dir, p)
+		if err != nil {
+			return err
+		}
+		key := filepath.ToSlash(rel)
+		if !strings.HasPrefix(key, prefix) {
+			return nil
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		objects = append(objects, &StoredObject{
+			Key:         key,
+			URL:         s.URL(key),
+			Size:        info.Size(),
+			ContentType: mime.TypeByExtension(path.Ext(key)),
+			ModTime:     info.ModTime(),
+		})
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list files: %w", err)
+	}
+	return objects, nil
+}
+
+func (s *LocalStorage) Key(url string) (string, bool) {
+	return keyAfter(url, s.baseURL)
+}
+
+func (s *LocalStorage) Stat(ctx context.Context, key string) (*StoredObject, error) {
+	target, err := s.path(key)
+	if err != nil {
+		return nil, err
+	}
+	info, err := os.Stat(target)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, ErrObjectNotFound
+		}
+		return nil, fmt.Errorf("failed to stat file: %w", err)
+	}
+
+	return &StoredObject{
+		Key:         key,
+		URL:         s.URL(key),
+		Size:        info.Size(),
+		ContentType: mime.TypeByExtension(path.Ext(key)),
+		ModTime:     info.ModTime(),
+	}, nil
+}
@@ -0,0 +1,252 @@
+package services
+
+import (
+	"blog-api/internal/models"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	"github.com/microcosm-cc/bluemonday"
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/extension"
+	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/renderer"
+	"github.com/yuin/goldmark/renderer/html"
+	"github.com/yuin/goldmark/text"
+	"github.com/yuin/goldmark/util"
+)
+
+type RenderedContent struct {
+	HTML string            `json:"html"`
+	TOC  []models.TOCEntry `json:"toc"`
+}
+
+// MarkdownService renders post content to sanitized HTML. Results are cached
+// by a hash of the source, so each revision of a post is rendered once.
+type MarkdownService struct {
+	md     goldmark.Markdown
+	policy *bluemonday.Policy
+	cache  *lruCache[*RenderedContent]
+}
+
+func NewMarkdownService(sanitizer *SanitizerService) *MarkdownService {
+	md := goldmark.New(
+		goldmark.WithExtensions(extension.GFM, extension.Footnote),
+		goldmark.WithParserOptions(parser.WithAutoHeadingID()),
+		goldmark.WithRendererOptions(
+			html.WithUnsafe(),
+			renderer.WithNodeRenderers(util.Prioritized(&codeBlockRenderer{}, 100)),
+		),
+	)
+
+	return &MarkdownService{
+		md:     md,
+		policy: sanitizer.RenderPolicy(),
+		cache:  newLRUCache[*RenderedContent](512),
+	}
+}
+
+func (s *MarkdownService) Render(source string) (*RenderedContent, error) {
+	sum := sha256.Sum256([]byte(source))
+	key := hex.EncodeToString(sum[:])
+
+	if rendered, ok := s.cache.Get(key); ok {
+		return rendered, nil
+	}
+
+	rendered, err := s.render([]byte(source))
+	if err != nil {
+		return nil, err
+	}
+
+	s.cache.Add(key, rendered)
+	return rendered, nil
+}
+
+func (s *MarkdownService) render(source []byte) (*RenderedContent, error) {
+	doc := s.md.Parser().Parse(text.NewReader(source))
+	toc := []models.TOCEntry{}
+
+	err := ast.Walk(doc, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		heading, ok := n.(*ast.Heading)
+		if !ok || !entering {
+			return ast.WalkContinue, nil
+		}
+
+		idAttr, _ := heading.AttributeString("id")
+		idBytes, ok := idAttr.([]byte)
+		if !ok || len(idBytes) == 0 {
+			return ast.WalkSkipChildren, nil
+		}
+		id := string(idBytes)
+		toc = append(toc, models.TOCEntry{
+			Level: heading.Level,
+			Text:  string(heading.Text(source)),
+			ID:    id,
+		})
+
+		anchor := ast.NewLink()
+		anchor.Destination = []byte("#" + id)
+		anchor.SetAttributeString("class", []byte("heading-anchor"))
+		anchor.AppendChild(anchor, ast.NewString([]byte("#")))
+		heading.InsertBefore(heading, heading.FirstChild(), anchor)
+		return ast.WalkSkipChildren, nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to build table of contents: %w", err)
+	}
+
+	var buf bytes.Buffer
+	if err := s.md.Renderer().Render(&buf, source, doc); err != nil {
+		return nil, fmt.Errorf("failed to render markdown: %w", err)
+	}
+
+	return &RenderedContent{
+		HTML: s.policy.Sanitize(buf.String()),
+		TOC:  toc,
+	}, nil
+}
+
+// CodeRanges are the byte ranges of code blocks and code spans in a Markdown
+// source, for edits that must leave code samples alone.
+type CodeRanges [][2]int
+
+func (r CodeRanges) Contains(pos int) bool {
+	for _, span := range r {
+		if pos >= span[0] && pos < span[1] {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *MarkdownService) CodeRanges(source string) CodeRanges {
+	doc := s.md.Parser().Parse(text.NewReader([]byte(source)))
+	var code CodeRanges
+	ast.Walk(doc, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		if !entering {
+			return ast.WalkContinue, nil
+		}
+		if ranges, ok := codeSegments(n); ok {
+			code = append(code, ranges...)
+			return ast.WalkSkipChildren, nil
+		}
+		return ast.WalkContinue, nil
+	})
+	return code
+}
+
+// codeSegments returns the source ranges of a code block or code span, and
+// whether n is one.
+func codeSegments(n ast.Node) (CodeRanges, bool) {
+	switch node := n.(type) {
+	case *ast.FencedCodeBlock, *ast.CodeBlock:
+		lines := n.Lines()
+		if lines.Len() == 0 {
+			return nil, true
+		}
+		return CodeRanges{{lines.At(0).Start, lines.At(lines.Len() - 1).Stop}}, true
+	case *ast.CodeSpan:
+		var ranges CodeRanges
+		for child := node.FirstChild(); child != nil; child = child.NextSibling() {
+			if t, ok := child.(*ast.Text); ok {
+				ranges = append(ranges, [2]int{t.Segment.Start, t.Segment.Stop})
+			}
+		}
+		return ranges, true
+	}
+	return nil, false
+}
+
+const (
+	wordsPerMinute     = 230
+	secondsPerImage    = 12
+	secondsPerCodeLine = 3
+)
+
+// Stats counts prose words, headings, code blocks and images. Reading time
+// adds a few seconds per image and per line of code on top of the prose.
+func (s *MarkdownService) Stats(source string) models.ContentStats {
+	src := []byte(source)
+	doc := s.md.Parser().Parse(text.NewReader(src))
+
+	var stats models.ContentStats
+	codeLines := 0
+	ast.Walk(doc, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		if !entering {
+			return ast.WalkContinue, nil
+		}
+		switch node := n.(type) {
+		case *ast.Heading:
+			stats.HeadingCount++
+		case *ast.FencedCodeBlock, *ast.CodeBlock:
+			stats.CodeBlockCount++
+			codeLines += node.Lines().Len()
+			return ast.WalkSkipChildren, nil
+		case *ast.Image:
+			stats.ImageCount++
+			return ast.WalkSkipChildren, nil
+		case *ast.Text:
+			stats.WordCount += len(strings.Fields(string(node.Segment.Value(src))))
+		case *ast.HTMLBlock, *ast.RawHTML:
+			stats.ImageCount += strings.Count(strings.ToLower(string(nodeSource(node, src))), "<img")
+			return ast.WalkSkipChildren, nil
+		}
+		return ast.WalkContinue, nil
+	})
+
+	seconds := stats.WordCount*60/wordsPerMinute + stats.ImageCount*secondsPerImage + codeLines*secondsPerCodeLine
+	stats.ReadingTimeMinutes = (seconds + 59) / 60
+	if stats.ReadingTimeMinutes < 1 && stats.WordCount > 0 {
+		stats.ReadingTimeMinutes = 1
+	}
+	return stats
+}
+
+func nodeSource(n ast.Node, src []byte) []byte {
+	var buf bytes.Buffer
+	if raw, ok := n.(*ast.RawHTML); ok {
+		for i := 0; i < raw.Segments.Len(); i++ {
+			segment := raw.Segments.At(i)
+			buf.Write(segment.Value(src))
+		}
+		return buf.Bytes()
+	}
+	lines := n.Lines()
+	for i := 0; i < lines.Len(); i++ {
+		segment := lines.At(i)
+		buf.Write(segment.Value(src))
+	}
+	return buf.Bytes()
+}
+
+// PlainText returns the prose of a Markdown document without markup or code,
+// for use in descriptions and summaries.
+func (s *MarkdownService) PlainText(source string) string {
+	src := []byte(source)
+	doc := s.md.Parser().Parse(text.NewReader(src))
+
+	var b strings.Builder
+	ast.Walk(doc, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		switch node := n.(type) {
+		case *ast.FencedCodeBlock, *ast.CodeBlock, *ast.HTMLBlock, *ast.RawHTML:
+			return ast.WalkSkipChildren, nil
+		case *ast.Text:
+			if entering {
+				b.Write(node.Segment.Value(src))
+				if node.SoftLineBreak() || node.HardLineBreak() {
+					b.WriteByte(' ')
+				}
+			}
+		case *ast.Paragraph, *ast.Heading:
+			if !entering {
+				b.WriteByte(' ')
+			}
+		}
+		return ast.WalkContinue, nil
+	})
+	return strings.Join(strings.Fields(b.String()), " ")
+}
@@ -0,0 +1,288 @@
+package services
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"log"
+	"os"
+	"strings"
+
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/gofont/gobold"
+	"golang.org/x/image/font/gofont/goregular"
+	"golang.org/x/image/font/opentype"
+	"golang.org/x/image/math/fixed"
+)
+
+const (
+	OGImageWidth  = 1200
+	OGImageHeight = 630
+	ogPadding     = 80
+)
+
+var (
+	ogBackgroundTop    = color.RGBA{0x0f, 0x17, 0x2a, 0xff}
+	ogBackgroundBottom = color.RGBA{0x1e, 0x29, 0x3b, 0xff}
+	ogAccent           = color.RGBA{0x38, 0xbd, 0xf8, 0xff}
+	ogText             = color.RGBA{0xf8, 0xfa, 0xfc, 0xff}
+	ogMuted            = color.RGBA{0x94, 0xa3, 0xb8, 0xff}
+)
+
+type OGCard struct {
+	Title    string
+	Category string
+	Author   string
+	Site     string
+	Version  string
+}
+
+func (card OGCard) cacheKey() string {
+	sum := sha256.Sum256([]byte(strings.Join([]string{card.Title, card.Category, card.Author, card.Site, card.Version}, "\x00")))
+	return hex.EncodeToString(sum[:])
+}
+
+// Fallback fonts for scripts the Go fonts lack, such as Devanagari. The
+// Docker image installs these; other paths can be given in OG_FALLBACK_FONTS.
+var defaultFallbackFonts = []string{
+	"/usr/share/fonts/noto/NotoSansDevanagari-Bold.ttf",
+	"/usr/share/fonts/noto/NotoSansDevanagari-Regular.ttf",
+}
+
+// OGImageService draws 1200×630 social preview cards with the Go fonts that
+// ship inside golang.org/x/image. Characters they do not cover are drawn
+// from the fallback fonts, when any are installed.
+type OGImageService struct {
+	bold      *opentype.Font
+	regular   *opentype.Font
+	fallbacks []*opentype.Font
+	cache     *lruCache[[]byte]
+}
+
+func NewOGImageService() (*OGImageService, error) {
+	bold, err := opentype.Parse(gobold.TTF)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse bold font: %w", err)
+	}
+	regular, err := opentype.Parse(goregular.TTF)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse regular font: %w", err)
+	}
+
+	paths := defaultFallbackFonts
+	if configured := os.Getenv("OG_FALLBACK_FONTS"); configured != "" {
+		paths = strings.Split(configured, ",")
+	}
+	var fallbacks []*opentype.Font
+	for _, path := range paths {
+		data, err := os.ReadFile(strings.TrimSpace(path))
+		if err != nil {
+			continue
+		}
+		f, err := opentype.Parse(data)
+		if err != nil {
+			log.Printf("Warning: failed to parse fallback font %s: %v", path, err)
+			continue
+		}
+		fallbacks = append(fallbacks, f)
+	}
+
+	return &OGImageService{
+		bold:      bold,
+		regular:   regular,
+		fallbacks: fallbacks,
+		cache:     newLRUCache[[]byte](128),
+	}, nil
+}
+
+// Render returns the PNG for card and the cache key, which doubles as an ETag.
+func (s *OGImageService) Render(card OGCard) ([]byte, string, error) {
+	key := card.cacheKey()
+	if data, ok := s.cache.Get(key); ok {
+		return data, key, nil
+	}
+
+	img := image.NewRGBA(image.Rect(0, 0, OGImageWidth, OGImageHeight))
+	for y := 0; y < OGImageHeight; y++ {
+		t := float64(y) / float64(OGImageHeight-1)
+		row := lerpColor(ogBackgroundTop, ogBackgroundBottom, t)
+		draw.Draw(img, image.Rect(0, y, OGImageWidth, y+1), &image.Uniform{row}, image.Point{}, draw.Src)
+	}
+	draw.Draw(img, image.Rect(0, 0, 16, OGImageHeight), &image.Uniform{ogAccent}, image.Point{}, draw.Src)
+
+	maxWidth := OGImageWidth - 2*ogPadding
+
+	if card.Category != "" {
+		face, err := s.face(s.bold, 28)
+		if err != nil {
+			return nil, "", err
+		}
+		drawText(img, face, ogAccent, ogPadding, ogPadding+28, strings.ToUpper(card.Category))
+		face.Close()
+	}
+
+	titleFace, lines, err := s.fitTitle(card.Title, maxWidth)
+	if err != nil {
+		return nil, "", err
+	}
+	lineHeight := titleFace.Metrics().Height.Ceil() + 8
+	blockHeight := lineHeight * len(lines)
+	y := (OGImageHeight-blockHeight)/2 + titleFace.Metrics().Ascent.Ceil()
+	for _, line := range lines {
+		drawText(img, titleFace, ogText, ogPadding, y, line)
+		y += lineHeight
+	}
+	titleFace.Close()
+
+	footerFace, err := s.face(s.regular, 30)
+	if err != nil {
+		return nil, "", err
+	}
+	defer footerFace.Close()
+	footerY := OGImageHeight - ogPadding
+	if card.Author != "" {
+		drawText(img, footerFace, ogMuted, ogPadding, footerY, card.Author)
+	}
+	if card.Site != "" {
+		width := font.MeasureString(footerFace, card.Site).Ceil()
+		drawText(img, footerFace, ogText, OGImageWidth-ogPadding-width, footerY, card.Site)
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, "", fmt.Errorf("failed to encode image: %w", err)
+	}
+
+	data := buf.Bytes()
+	s.cache.Add(key, data)
+	return data, key, nil
+}
+
+// fitTitle picks the largest font size at which the title wraps into at most
+// four lines, truncating with an ellipsis at the smallest size.
+func (s *OGImageService) fitTitle(title string, maxWidth int) (font.Face, []string, error) {
+	sizes := []float64{76, 68, 60, 52, 46}
+	for i, size := range sizes {
+		face, err := s.face(s.bold, size)
+		if err != nil {
+			return nil, nil, err
+		}
+		lines := wrapText(face, title, maxWidth)
+		if len(lines) <= 4 {
+			return face, lines, nil
+		}
+		if i == len(sizes)-1 {
+			lines = lines[:4]
+			lines[3] = strings.TrimRight(lines[3], " .,;:") + "…"
+			for font.MeasureString(face, lines[3]).Ceil() > maxWidth && len([]rune(lines[3])) > 1 {
+				runes := []rune(lines[3])
+				lines[3] = string(runes[:len(runes)-2]) + "…"
+			}
+			return face, lines, nil
+		}
+		face.Close()
+	}
+	return nil, nil, fmt.Errorf("no font sizes configured")
+}
+
+func (s *OGImageService) face(f *opentype.Font, size float64) (font.Face, error) {
+	faces := make(fallbackFace, 0, 1+len(s.fallbacks))
+	for _, f := range append([]*opentype.Font{f}, s.fallbacks...) {
+		face, err := opentype.NewFace(f, &opentype.FaceOptions{Size: size, DPI: 72, Hinting: font.HintingFull})
+		if err != nil {
+			faces.Close()
+			return nil, fmt.Errorf("failed to create font face: %w", err)
+		}
+		faces = append(faces, face)
+	}
+	if len(faces) == 1 {
+		return faces[0], nil
+	}
+	return faces, nil
+}
+
+// fallbackFace draws each character with the first face that has a glyph
+// for it. Metrics come from the first face. Glyphs are placed one by one, so
+// scripts that need shaping, like Devanagari conjuncts, are only
+// approximated.
+type fallbackFace []font.Face
+
+func (f fallbackFace) pick(r rune) font.Face {
+	for _, face := range f {
+		if _, ok := face.GlyphAdvance(r); ok {
+			return face
+		}
+	}
+	return f[0]
+}
+
+func (f fallbackFace) Close() error {
+	for _, face := range f {
+		face.Close()
+	}
+	return nil
+}
+
+func (f fallbackFace) Glyph(dot fixed.Point26_6, r rune) (image.Rectangle, image.Image, image.Point, fixed.Int26_6, bool) {
+	return f.pick(r).Glyph(dot, r)
+}
+
+func (f fallbackFace) GlyphBounds(r rune) (fixed.Rectangle26_6, fixed.Int26_6, bool) {
+	return f.pick(r).GlyphBounds(r)
+}
+
+func (f fallbackFace) GlyphAdvance(r rune) (fixed.Int26_6, bool) {
+	return f.pick(r).GlyphAdvance(r)
+}
+
+func (f fallbackFace) Kern(r0, r1 rune) fixed.Int26_6 {
+	if face := f.pick(r0); face == f.pick(r1) {
+		return face.Kern(r0, r1)
+	}
+	return 0
+}
+
+func (f fallbackFace) Metrics() font.Metrics {
+	return f[0].Metrics()
+}
+
+func wrapText(face font.Face, text string, maxWidth int) []string {
+	var lines []string
+	current := ""
+	for _, word := range strings.Fields(text) {
+		candidate := word
+		if current != "" {
+			candidate = current + " " + word
+		}
+		if font.MeasureString(face, candidate).Ceil() <= maxWidth || current == "" {
+			current = candidate
+			continue
+		}
+		lines = append(lines, current)
+		current = word
+	}
+	if current != "" {
+		lines = append(lines, current)
+	}
+	return lines
+}
+
+func drawText(img draw.Image, face font.Face, c color.Color, x, y int, text string) {
+	d := &font.Drawer{
+		Dst:  img,
+		Src:  image.NewUniform(c),
+		Face: face,
+		Dot:  fixed.P(x, y),
+	}
+	d.DrawString(text)
+}
+
+func lerpColor(a, b color.RGBA, t float64) color.RGBA {
+	mix := func(x, y uint8) uint8 { return uint8(float64(x) + (float64(y)-float64(x))*t) }
+	return color.RGBA{mix(a.R, b.R), mix(a.G, b.G), mix(a.B, b.B), 0xff}
+}
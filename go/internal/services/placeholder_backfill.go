@@ -0,0 +1,106 @@
+package services
+
+import (
+	"blog-api/internal/models"
+	"context"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Fetch reads an image back by its public URL: through the storage backend
+// when it produced the URL, and over HTTP for anything else.
+func (s *AssetService) Fetch(ctx context.Context, url string) (io.ReadCloser, error) {
+	if key, ok := s.storage.Key(url); ok {
+		return s.storage.Open(ctx, key)
+	}
+	if strings.HasPrefix(url, "https://") || strings.HasPrefix(url, "http://") {
+		return fetchURL(ctx, url)
+	}
+	return nil, fmt.Errorf("cannot fetch %s: not a URL of the %s storage", url, s.storage.Name())
+}
+
+// BackfillPlaceholders computes placeholders for media entries and featured
+// images that have none yet, or for all of them when force is set. Each URL
+// is fetched once, and a featured image taken from the media library reuses
+// the entry's placeholder.
+func (s *AssetService) BackfillPlaceholders(ctx context.Context, images *ImageService, force bool) (*models.PlaceholderReport, error) {
+	report := &models.PlaceholderReport{Failed: []*models.PlaceholderFailure{}}
+	known := make(map[string]*models.ImagePlaceholder)
+
+	placeholderFor := func(url string) *models.ImagePlaceholder {
+		if placeholder, ok := known[url]; ok {
+			return placeholder
+		}
+		placeholder, err := s.computePlaceholder(ctx, images, url)
+		if err != nil {
+			report.Failed = append(report.Failed, &models.PlaceholderFailure{URL: url, Error: err.Error()})
+		}
+		// Failures are remembered too, so a broken URL is only tried once.
+		known[url] = placeholder
+		return placeholder
+	}
+
+	media, err := s.mediaRepo.GetAllImages()
+	if err != nil {
+		return nil, err
+	}
+	for _, m := range media {
+		if err := ctx.Err(); err != nil {
+			return report, err
+		}
+		if m.Blurhash != "" && !force {
+			known[m.URL] = m.Placeholder()
+			report.Skipped++
+			continue
+		}
+		placeholder := placeholderFor(m.URL)
+		if placeholder == nil {
+			continue
+		}
+		if err := s.mediaRepo.SetPlaceholder(m.ID, placeholder); err != nil {
+			return report, err
+		}
+		report.Media++
+	}
+
+	blogs, err := s.blogRepo.GetFeaturedImages()
+	if err != nil {
+		return nil, err
+	}
+	for _, blog := range blogs {
+		if err := ctx.Err(); err != nil {
+			return report, err
+		}
+		if blog.FeaturedImageBlurhash != nil && !force {
+			report.Skipped++
+			continue
+		}
+		url := *blog.FeaturedImage
+		placeholder := placeholderFor(url)
+		if placeholder == nil {
+			continue
+		}
+		if err := s.blogRepo.SetFeaturedImageMeta(blog.ID, url, placeholder.FeaturedImageMeta()); err != nil {
+			return report, err
+		}
+		report.Blogs++
+	}
+
+	return report, nil
+}
+
+func (s *AssetService) computePlaceholder(ctx context.Context, images *ImageService, url string) (*models.ImagePlaceholder, error) {
+	body, err := s.Fetch(ctx, url)
+	if err != nil {
+		return nil, err
+	}
+	defer body.Close()
+
+	image, err := images.Prepare(body)
+	if err != nil {
+		return nil, err
+	}
+	defer image.Close()
+	return images.Placeholder(image)
+}
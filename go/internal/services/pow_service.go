@@ -0,0 +1,197 @@
+package services
+
+import (
+	"blog-api/internal/repository"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"math/bits"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ProofOfWorkService issues hashcash-style challenges: the client must find a
+// solution such that sha256(challenge + solution) starts with `difficulty`
+// zero bits. Challenges are HMAC-signed so no server state is needed until
+// one is redeemed; redeemed ones are recorded in the database until they
+// expire, so every instance refuses a replay.
+type ProofOfWorkService struct {
+	secret         []byte
+	baseDifficulty int
+	maxDifficulty  int
+	ttl            time.Duration
+	pressure       func(ip string) (float64, int)
+	repo           *repository.ChallengeRepository
+}
+
+type Challenge struct {
+	Challenge  string    `json:"challenge"`
+	Difficulty int       `json:"difficulty"`
+	Algorithm  string    `json:"algorithm"`
+	IssuedAt   time.Time `json:"issued_at"`
+	ExpiresAt  time.Time `json:"expires_at"`
+}
+
+// NewProofOfWorkService requires POW_SECRET rather than generating one, so
+// challenges survive restarts and verify on every instance.
+func NewProofOfWorkService(pressure func(ip string) (float64, int)) (*ProofOfWorkService, error) {
+	secret := []byte(os.Getenv("POW_SECRET"))
+	if len(secret) == 0 {
+		return nil, fmt.Errorf("POW_SECRET not set")
+	}
+
+	base := envInt("POW_DIFFICULTY", 16)
+	max := envInt("POW_MAX_DIFFICULTY", 24)
+	if max < base {
+		max = base
+	}
+
+	s := &ProofOfWorkService{
+		secret:         secret,
+		baseDifficulty: base,
+		maxDifficulty:  max,
+		ttl:            time.Duration(envInt("POW_TTL_SECONDS", 300)) * time.Second,
+		pressure:       pressure,
+		repo:           repository.NewChallengeRepository(),
+	}
+
+	go s.purgeSpent()
+	return s, nil
+}
+
+func envInt(key string, def int) int {
+	if parsed, err := strconv.Atoi(os.Getenv(key)); err == nil && parsed > 0 {
+		return parsed
+	}
+	return def
+}
+
+// DifficultyFor raises the base difficulty by up to 4 bits as ip approaches
+// its rate limit and by one more bit per recent rejection.
+func (s *ProofOfWorkService) DifficultyFor(ip string) int {
+	difficulty := s.baseDifficulty
+	if s.pressure != nil {
+		used, rejected := s.pressure(ip)
+		difficulty += int(used * 4)
+		if rejected > 4 {
+			rejected = 4
+		}
+		difficulty += rejected
+	}
+	if difficulty > s.maxDifficulty {
+		difficulty = s.maxDifficulty
+	}
+	return difficulty
+}
+
+func (s *ProofOfWorkService) Issue(ip string) *Challenge {
+	nonce := make([]byte, 16)
+	rand.Read(nonce)
+
+	difficulty := s.DifficultyFor(ip)
+	issuedAt := time.Now().Truncate(time.Second)
+	expiresAt := issuedAt.Add(s.ttl)
+	payload := fmt.Sprintf("v2.%s.%d.%d.%d", hex.EncodeToString(nonce), difficulty, issuedAt.Unix(), expiresAt.Unix())
+	signature := base64.RawURLEncoding.EncodeToString(s.sign(payload, ip))
+
+	return &Challenge{
+		Challenge:  payload + "." + signature,
+		Difficulty: difficulty,
+		Algorithm:  "sha256",
+		IssuedAt:   issuedAt,
+		ExpiresAt:  expiresAt,
+	}
+}
+
+// Verify checks the signature, expiry, IP binding and work of a solved
+// challenge, and burns it so it cannot be replayed. It returns when the
+// challenge was issued, a time the client cannot forge.
+func (s *ProofOfWorkService) Verify(challenge, solution, ip string) (time.Time, error) {
+	if challenge == "" || solution == "" {
+		return time.Time{}, fmt.Errorf("proof of work is required")
+	}
+	if len(solution) > 64 {
+		return time.Time{}, fmt.Errorf("invalid proof of work solution")
+	}
+
+	parts := strings.Split(challenge, ".")
+	if len(parts) != 6 || parts[0] != "v2" {
+		return time.Time{}, fmt.Errorf("malformed challenge")
+	}
+
+	payload := strings.Join(parts[:5], ".")
+	signature, err := base64.RawURLEncoding.DecodeString(parts[5])
+	if err != nil || !hmac.Equal(signature, s.sign(payload, ip)) {
+		return time.Time{}, fmt.Errorf("invalid challenge signature")
+	}
+
+	difficulty, err := strconv.Atoi(parts[2])
+	if err != nil {
+		return time.Time{}, fmt.Errorf("malformed challenge")
+	}
+	issuedUnix, err := strconv.ParseInt(parts[3], 10, 64)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("malformed challenge")
+	}
+	expiresUnix, err := strconv.ParseInt(parts[4], 10, 64)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("malformed challenge")
+	}
+	expiresAt := time.Unix(expiresUnix, 0)
+	if time.Now().After(expiresAt) {
+		return time.Time{}, fmt.Errorf("challenge expired")
+	}
+
+	sum := sha256.Sum256([]byte(challenge + solution))
+	if leadingZeroBits(sum[:]) < difficulty {
+		return time.Time{}, fmt.Errorf("insufficient proof of work")
+	}
+
+	if err := s.repo.Spend(parts[1], expiresAt); err != nil {
+		if errors.Is(err, repository.ErrChallengeSpent) {
+			return time.Time{}, err
+		}
+		log.Printf("Warning: %v", err)
+		return time.Time{}, fmt.Errorf("failed to verify challenge")
+	}
+	return time.Unix(issuedUnix, 0), nil
+}
+
+func (s *ProofOfWorkService) sign(payload, ip string) []byte {
+	mac := hmac.New(sha256.New, s.secret)
+	mac.Write([]byte(payload))
+	mac.Write([]byte{0})
+	mac.Write([]byte(ip))
+	return mac.Sum(nil)
+}
+
+// purgeSpent drops redeemed challenges once they have expired and could no
+// longer be replayed anyway.
+func (s *ProofOfWorkService) purgeSpent() {
+	for {
+		time.Sleep(time.Minute)
+		if _, err := s.repo.PurgeExpired(time.Now()); err != nil {
+			log.Printf("Warning: %v", err)
+		}
+	}
+}
+
+func leadingZeroBits(sum []byte) int {
+	count := 0
+	for _, b := range sum {
+		if b == 0 {
+			count += 8
+			continue
+		}
+		count += bits.LeadingZeros8(b)
+		break
+	}
+	return count
+}
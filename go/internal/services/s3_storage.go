@@ -0,0 +1,250 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+)
+
+// S3Storage talks to AWS S3 or any S3-compatible store (MinIO, R2, B2,
+// Spaces, ...).
+type S3Storage struct {
+	client  *minio.Client
+	bucket  string
+	prefix  string
+	baseURL string
+}
+
+func NewS3Storage() (*S3Storage, error) {
+	endpoint := os.Getenv("S3_ENDPOINT")
+	bucket := os.Getenv("S3_BUCKET")
+	if endpoint == "" || bucket == "" {
+		return nil, fmt.Errorf("S3_ENDPOINT and S3_BUCKET environment variables must be set")
+	}
+
+	secure := true
+	if u, err := url.Parse(endpoint); err == nil && u.Host != "" {
+		secure = u.Scheme == "https"
+		endpoint = u.Host
+	}
+	if v, err := strconv.ParseBool(os.Getenv("S3_USE_SSL")); err == nil {
+		secure = v
+	}
+
+	lookup := minio.BucketLookupAuto
+	if forcePathStyle, _ := strconv.ParseBool(os.Getenv("S3_FORCE_PATH_STYLE")); forcePathStyle {
+		lookup = minio.BucketLookupPath
+	}
+
+	client, err := minio.New(endpoint, &minio.Options{
+		Creds:        credentials.NewStaticV4(os.Getenv("S3_ACCESS_KEY_ID"), os.Getenv("S3_SECRET_ACCESS_KEY"), ""),
+		Secure:       secure,
+		Region:       os.Getenv("S3_REGION"),
+		BucketLookup: lookup,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize S3 client: %w", err)
+	}
+
+	baseURL := strings.TrimRight(os.Getenv("S3_PUBLIC_URL"), "/")
+	if baseURL == "" {
+		scheme := "https"
+		if !secure {
+			scheme = "http"
+		}
+		baseURL = scheme + "://" + endpoint + "/" + bucket
+	}
+
+	return &S3Storage{
+		client:  client,
+		bucket:  bucket,
+		prefix:  strings.Trim(os.Getenv("S3_PREFIX"), "/"),
+		baseURL: baseURL,
+	}, nil
+}
+
+func (s *S3Storage) Name() string {
+	return "s3"
+}
+
+func (s *S3Storage) objectName(key string) (string, error) {
+	key, err := cleanKey(key)
+	if err != nil {
+		return "", err
+	}
+	if s.prefix == "" {
+		return key, nil
+	}
+	return path.Join(s.prefix, key), nil
+}
+
+func (s *S3Storage) Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) (*StoredObject, error) {
+	name, err := s.objectName(key)
+	if err != nil {
+		return nil, err
+	}
+
+	info, err := s.client.PutObject(ctx, s.bucket, name, r, size, minio.PutObjectOptions{
+		ContentType:  contentType,
+		CacheControl: "public, max-age=31536000, immutable",
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to upload object to S3: %w", err)
+	}
+
+	return &StoredObject{
+		Key:         key,
+		URL:         s.URL(key),
+		Size:        info.Size,
+		ContentType: contentType,
+		ModTime:     info.LastModified,
+	}, nil
+}
+
+// Delete reports ErrObjectNotFound for missing keys; S3 itself treats
+// deleting a missing object as success.
+func (s *S3Storage) Delete(ctx context.Context, key string) error {
+	if _, err := s.Stat(ctx, key); err != nil {
+		return err
+	}
+
+	name, err := s.objectName(key)
+	if err != nil {
+		return err
+	}
+	if err := s.client.RemoveObject(ctx, s.bucket, name, minio.RemoveObjectOptions{}); err != nil {
+		return fmt.Errorf("failed to delete object from S3: %w", err)
+	}
+	return nil
+}
+
+// Open checks the object exists first, because GetObject only reports a
+// missing key on the first read.
+func (s *S3Storage) Open(ctx context.Context, key string) (io.ReadCloser, error) {
+	if _, err := s.Stat(ctx, key); err != nil {
+		return nil, err
+	}
+
+	name, err := s.objectName(key)
+	if err != nil {
+		return nil, err
+	}
+	object, err := s.client.GetObject(ctx, s.bucket, name, minio.GetObjectOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get object from S3: %w", err)
+	}
+	return object, nil
+}
+
+// PresignUpload returns a POST policy, which unlike a presigned PUT lets S3
+// enforce the content type and maximum size.
+func (s *S3Storage) PresignUpload(ctx context.Context, key, contentType string, maxBytes int64, expiresAt time.Time) (*DirectUpload, error) {
+	name, err := s.objectName(key)
+	if err != nil {
+		return nil, err
+	}
+
+	policy := minio.NewPostPolicy()
+	if err := policy.SetBucket(s.bucket); err != nil {
+		return nil, err
+	}
+	if err := policy.SetKey(name); err != nil {
+		return nil, err
+	}
+	if err := policy.SetExpires(expiresAt.UTC()); err != nil {
+		return nil, err
+	}
+	if err := policy.SetContentType(contentType); err != nil {
+		return nil, err
+	}
+	if err := policy.SetContentLengthRange(1, maxBytes); err != nil {
+		return nil, err
+	}
+
+	u, fields, err := s.client.PresignedPostPolicy(ctx, policy)
+	if err != nil {
+		return nil, fmt.Errorf("failed to presign S3 upload: %w", err)
+	}
+	return &DirectUpload{
+		Method:    http.MethodPost,
+		URL:       u.String(),
+		Fields:    fields,
+		FileField: "file",
+		ExpiresAt: expiresAt,
+	}, nil
+}
+
+func (s *S3Storage) URL(key string) string {
+	name, err := s.objectName(key)
+	if err != nil {
+		return ""
+	}
+	return s.baseURL + "/" + name
+}
+
+func (s *S3Storage) List(ctx context.Context, prefix string) ([]*StoredObject, error) {
+	namePrefix := prefix
+	if s.prefix != "" {
+		namePrefix = s.prefix + "/" + prefix
+	}
+
+	var objects []*StoredObject
+	for info := range s.client.ListObjects(ctx, s.bucket, minio.ListObjectsOptions{Prefix: namePrefix, Recursive: true}) {
+		if info.Err != nil {
+			return nil, fmt.Errorf("failed to list objects on S3: %w", info.Err)
+		}
+		key := info.Key
+		if s.prefix != "" {
+			key = strings.TrimPrefix(key, s.prefix+"/")
+		}
+		objects = append(objects, &StoredObject{
+			Key:         key,
+			URL:         s.URL(key),
+			Size:        info.Size,
+			ContentType: info.ContentType,
+			ModTime:     info.LastModified,
+		})
+	}
+	return objects, nil
+}
+
+func (s *S3Storage) Key(url string) (string, bool) {
+	base := s.baseURL
+	if s.prefix != "" {
+		base += "/" + s.prefix
+	}
+	return keyAfter(url, base)
+}
+
+func (s *S3Storage) Stat(ctx context.Context, key string) (*StoredObject, error) {
+	name, err := s.objectName(key)
+	if err != nil {
+		return nil, err
+	}
+
+	info, err := s.client.StatObject(ctx, s.bucket, name, minio.StatObjectOptions{})
+	if err != nil {
+		if minio.ToErrorResponse(err).Code == "NoSuchKey" {
+			return nil, ErrObjectNotFound
+		}
+		return nil, fmt.Errorf("failed to stat object on S3: %w", err)
+	}
+
+	return &StoredObject{
+		Key:         key,
+		URL:         s.URL(key),
+		Size:        info.Size,
+		ContentType: info.ContentType,
+		ModTime:     info.LastModified,
+	}, nil
+}
@@ -0,0 +1,477 @@
+package services
+
+import (
+	"blog-api/internal/models"
+	"bytes"
+	"fmt"
+	"net/url"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/microcosm-cc/bluemonday"
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/extension"
+	"github.com/yuin/goldmark/text"
+	"golang.org/x/net/html"
+)
+
+const (
+	SanitizeModeStrip  = "strip"
+	SanitizeModeReject = "reject"
+)
+
+var defaultAllowedTags = map[string][]string{
+	"a":          {"href", "name", "target", "rel"},
+	"abbr":       {},
+	"b":          {},
+	"blockquote": {"cite"},
+	"br":         {},
+	"caption":    {},
+	"cite":       {},
+	"code":       {},
+	"col":        {"span"},
+	"colgroup":   {"span"},
+	"dd":         {},
+	"del":        {"datetime"},
+	"details":    {"open"},
+	"dfn":        {},
+	"div":        {},
+	"dl":         {},
+	"dt":         {},
+	"em":         {},
+	"figcaption": {},
+	"figure":     {},
+	"h1":         {},
+	"h2":         {},
+	"h3":         {},
+	"h4":         {},
+	"h5":         {},
+	"h6":         {},
+	"hr":         {},
+	"i":          {},
+	"iframe":     {"src", "width", "height", "title", "allow", "allowfullscreen", "frameborder", "loading"},
+	"img":        {"src", "alt", "width", "height", "loading"},
+	"ins":        {"datetime"},
+	"kbd":        {},
+	"li":         {"value"},
+	"mark":       {},
+	"ol":         {"start", "type", "reversed"},
+	"p":          {},
+	"pre":        {},
+	"q":          {"cite"},
+	"s":          {},
+	"samp":       {},
+	"small":      {},
+	"span":       {},
+	"strong":     {},
+	"sub":        {},
+	"summary":    {},
+	"sup":        {},
+	"table":      {},
+	"tbody":      {},
+	"td":         {"colspan", "rowspan", "align"},
+	"tfoot":      {},
+	"th":         {"colspan", "rowspan", "align", "scope"},
+	"thead":      {},
+	"time":       {"datetime"},
+	"tr":         {},
+	"u":          {},
+	"ul":         {},
+	"var":        {},
+}
+
+var globalAllowedAttrs = map[string]bool{"class": true, "id": true, "title": true, "lang": true, "dir": true}
+
+// Elements whose content is dropped along with the tag itself.
+var dropContentTags = map[string]bool{
+	"script": true, "style": true, "template": true, "noscript": true, "object": true,
+	"embed": true, "applet": true, "frame": true, "frameset": true, "svg": true, "math": true,
+	"base": true, "meta": true, "link": true, "form": true, "input": true, "button": true,
+	"textarea": true, "select": true,
+}
+
+var urlAttrs = map[string]bool{"href": true, "src": true, "cite": true}
+
+var defaultIframeHosts = []string{"youtube.com", "youtube-nocookie.com", "player.vimeo.com", "codepen.io", "codesandbox.io", "stackblitz.com"}
+
+// Link destinations in Markdown source: inline links, reference
+// definitions and autolinks. Destinations are checked after entity decoding,
+// since the renderer decodes them too.
+var (
+	markdownLinkDest     = regexp.MustCompile(`(\]\(\s*<?)((?:[^()\s>]|\([^()\s]*\))+)`)
+	markdownDefDest      = regexp.MustCompile(`(?m)^(\s{0,3}\[[^\]]+\]:\s*<?)(\S+)`)
+	markdownAutolinkDest = regexp.MustCompile(`(<)([A-Za-z][A-Za-z0-9+.-]{1,31}:[^<>\x00-\x20]*)>`)
+)
+
+var dangerousSchemes = map[string]bool{"javascript": true, "vbscript": true, "data": true}
+
+// Removing markup can join the text around it into new markup, so sanitizing
+// repeats until a pass finds nothing. Input still changing after this many
+// passes has every tag opener escaped instead.
+const maxSanitizePasses = 16
+
+// SanitizerService enforces one configurable HTML allowlist, both when posts
+// are written (producing a report of what was removed) and when their
+// Markdown is rendered.
+type SanitizerService struct {
+	mode        string
+	allowedTags map[string][]string
+	iframeHosts []string
+	md          goldmark.Markdown
+}
+
+func NewSanitizerService() *SanitizerService {
+	mode := strings.ToLower(os.Getenv("SANITIZE_MODE"))
+	if mode != SanitizeModeReject {
+		mode = SanitizeModeStrip
+	}
+
+	allowedTags := make(map[string][]string, len(defaultAllowedTags))
+	for tag, attrs := range defaultAllowedTags {
+		allowedTags[tag] = attrs
+	}
+	for _, tag := range strings.Split(os.Getenv("SANITIZE_DISALLOWED_TAGS"), ",") {
+		delete(allowedTags, strings.ToLower(strings.TrimSpace(tag)))
+	}
+
+	iframeHosts := defaultIframeHosts
+	if hosts := os.Getenv("SANITIZE_IFRAME_HOSTS"); hosts != "" {
+		iframeHosts = nil
+		for _, host := range strings.Split(hosts, ",") {
+			if host = strings.ToLower(strings.TrimSpace(host)); host != "" {
+				iframeHosts = append(iframeHosts, host)
+			}
+		}
+	}
+
+	return &SanitizerService{
+		mode:        mode,
+		allowedTags: allowedTags,
+		iframeHosts: iframeHosts,
+		md:          goldmark.New(goldmark.WithExtensions(extension.GFM, extension.Footnote)),
+	}
+}
+
+func (s *SanitizerService) Mode() string {
+	return s.mode
+}
+
+// SanitizeText strips every tag from a plain-text field such as a title.
+func (s *SanitizerService) SanitizeText(field, value string) (string, []models.SanitizeFinding) {
+	if !strings.ContainsAny(value, "<>") {
+		return value, nil
+	}
+	cleaned, findings := s.untilClean(field, value, func(value string) (string, []models.SanitizeFinding) {
+		return s.sanitizeHTML(field, value, false)
+	})
+	return strings.TrimSpace(cleaned), findings
+}
+
+// SanitizeMarkdown cleans Markdown content. Only raw HTML blocks and inline
+// HTML are rewritten, so code samples that merely mention <script> survive
+// untouched; dangerous link destinations are neutralised to "#".
+func (s *SanitizerService) SanitizeMarkdown(field, source string) (string, []models.SanitizeFinding) {
+	return s.untilClean(field, source, func(source string) (string, []models.SanitizeFinding) {
+		return s.sanitizeMarkdownPass(field, source)
+	})
+}
+
+func (s *SanitizerService) untilClean(field, value string, pass func(string) (string, []models.SanitizeFinding)) (string, []models.SanitizeFinding) {
+	var findings []models.SanitizeFinding
+	for i := 0; i < maxSanitizePasses; i++ {
+		cleaned, passFindings := pass(value)
+		if len(passFindings) == 0 {
+			return value, findings
+		}
+		findings = append(findings, passFindings...)
+		value = cleaned
+	}
+	findings = append(findings, models.SanitizeFinding{Field: field, Type: "tag", Detail: "escaped deeply nested markup"})
+	return strings.ReplaceAll(value, "<", "&lt;"), findings
+}
+
+func (s *SanitizerService) sanitizeMarkdownPass(field, source string) (string, []models.SanitizeFinding) {
+	src := []byte(source)
+	doc := s.md.Parser().Parse(text.NewReader(src))
+
+	type replacement struct {
+		start, stop int
+		value       string
+	}
+	var replacements []replacement
+	var code CodeRanges
+	var findings []models.SanitizeFinding
+
+	sanitizeSegment := func(start, stop int) {
+		cleaned, segmentFindings := s.sanitizeHTML(field, string(src[start:stop]), true)
+		if len(segmentFindings) > 0 {
+			findings = append(findings, segmentFindings...)
+			replacements = append(replacements, replacement{start, stop, cleaned})
+		}
+	}
+
+	ast.Walk(doc, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		if !entering {
+			return ast.WalkContinue, nil
+		}
+		if ranges, ok := codeSegments(n); ok {
+			code = append(code, ranges...)
+			return ast.WalkSkipChildren, nil
+		}
+		switch node := n.(type) {
+		case *ast.HTMLBlock:
+			lines := node.Lines()
+			if lines.Len() > 0 {
+				start, stop := lines.At(0).Start, lines.At(lines.Len()-1).Stop
+				if node.HasClosure() {
+					stop = node.ClosureLine.Stop
+				}
+				sanitizeSegment(start, stop)
+			}
+			return ast.WalkSkipChildren, nil
+		case *ast.RawHTML:
+			if node.Segments.Len() > 0 {
+				sanitizeSegment(node.Segments.At(0).Start, node.Segments.At(node.Segments.Len()-1).Stop)
+			}
+			return ast.WalkSkipChildren, nil
+		}
+		return ast.WalkContinue, nil
+	})
+
+	for _, pattern := range []*regexp.Regexp{markdownLinkDest, markdownDefDest, markdownAutolinkDest} {
+		for _, match := range pattern.FindAllSubmatchIndex(src, -1) {
+			if code.Contains(match[0]) {
+				continue
+			}
+			scheme := urlScheme(html.UnescapeString(string(src[match[4]:match[5]])))
+			if !dangerousSchemes[scheme] {
+				continue
+			}
+			findings = append(findings, models.SanitizeFinding{
+				Field:  field,
+				Type:   "dangerous_url",
+				Detail: fmt.Sprintf("removed %s: link destination", scheme),
+			})
+			value := string(src[match[2]:match[3]]) + "#"
+			if pattern == markdownAutolinkDest {
+				value = ""
+			}
+			replacements = append(replacements, replacement{match[0], match[1], value})
+		}
+	}
+
+	if len(replacements) == 0 {
+		return source, nil
+	}
+
+	sort.Slice(replacements, func(i, j int) bool { return replacements[i].start > replacements[j].start })
+	out := src
+	lastStart := len(src) + 1
+	for _, r := range replacements {
+		if r.stop > lastStart {
+			continue
+		}
+		out = append(append(append([]byte{}, out[:r.start]...), r.value...), out[r.stop:]...)
+		lastStart = r.start
+	}
+	return string(out), findings
+}
+
+func (s *SanitizerService) sanitizeHTML(field, fragment string, allowTags bool) (string, []models.SanitizeFinding) {
+	var out bytes.Buffer
+	var findings []models.SanitizeFinding
+	report := func(kind, detail string) {
+		findings = append(findings, models.SanitizeFinding{Field: field, Type: kind, Detail: detail})
+	}
+
+	tokenizer := html.NewTokenizer(strings.NewReader(fragment))
+	skipUntil := ""
+	skipDepth := 0
+
+	for {
+		tt := tokenizer.Next()
+		if tt == html.ErrorToken {
+			break
+		}
+		raw := tokenizer.Raw()
+		token := tokenizer.Token()
+		tag := strings.ToLower(token.Data)
+
+		if skipUntil != "" {
+			switch {
+			case tt == html.StartTagToken && tag == skipUntil:
+				skipDepth++
+			case tt == html.EndTagToken && tag == skipUntil:
+				skipDepth--
+				if skipDepth == 0 {
+					skipUntil = ""
+				}
+			}
+			continue
+		}
+
+		switch tt {
+		case html.TextToken:
+			// Inside HTML the text is escaped, so a "<" left next to a
+			// removed tag cannot open a new one.
+			if allowTags {
+				out.WriteString(html.EscapeString(token.Data))
+			} else {
+				out.Write(raw)
+			}
+		case html.CommentToken, html.DoctypeToken:
+			report("comment", "removed HTML comment or doctype")
+		case html.StartTagToken, html.SelfClosingTagToken:
+			if !allowTags {
+				report("tag", fmt.Sprintf("removed <%s> tag", tag))
+				if dropContentTags[tag] && tt == html.StartTagToken {
+					skipUntil, skipDepth = tag, 1
+				}
+				continue
+			}
+			if dropContentTags[tag] {
+				report("element", fmt.Sprintf("removed <%s> element", tag))
+				if tt == html.StartTagToken {
+					skipUntil, skipDepth = tag, 1
+				}
+				continue
+			}
+			if tag == "iframe" && !s.allowedIframe(token) {
+				report("iframe", fmt.Sprintf("removed iframe from %q", attrValue(token, "src")))
+				if tt == html.StartTagToken {
+					skipUntil, skipDepth = tag, 1
+				}
+				continue
+			}
+			allowedAttrs, ok := s.allowedTags[tag]
+			if !ok {
+				report("tag", fmt.Sprintf("removed <%s> tag", tag))
+				continue
+			}
+			out.WriteString(s.cleanTag(token, tt, allowedAttrs, report))
+		case html.EndTagToken:
+			if _, ok := s.allowedTags[tag]; allowTags && ok {
+				out.WriteString("</" + tag + ">")
+				continue
+			}
+			report("tag", fmt.Sprintf("removed </%s> tag", tag))
+		}
+	}
+
+	return out.String(), findings
+}
+
+func (s *SanitizerService) cleanTag(token html.Token, tt html.TokenType, allowedAttrs []string, report func(string, string)) string {
+	tag := strings.ToLower(token.Data)
+	var b strings.Builder
+	b.WriteString("<" + tag)
+
+	for _, attr := range token.Attr {
+		key := strings.ToLower(attr.Key)
+		switch {
+		case strings.HasPrefix(key, "on"):
+			report("event_handler", fmt.Sprintf("removed %s attribute from <%s>", key, tag))
+			continue
+		case !globalAllowedAttrs[key] && !containsString(allowedAttrs, key):
+			report("attribute", fmt.Sprintf("removed %s attribute from <%s>", key, tag))
+			continue
+		case urlAttrs[key] && !safeURL(attr.Val):
+			report("dangerous_url", fmt.Sprintf("removed %s URL from <%s %s>", urlScheme(attr.Val), tag, key))
+			continue
+		}
+		b.WriteString(" " + key + `="` + html.EscapeString(attr.Val) + `"`)
+	}
+
+	if tt == html.SelfClosingTagToken {
+		b.WriteString(" /")
+	}
+	b.WriteString(">")
+	return b.String()
+}
+
+func (s *SanitizerService) allowedIframe(token html.Token) bool {
+	u, err := url.Parse(strings.TrimSpace(attrValue(token, "src")))
+	if err != nil || u.Scheme != "https" {
+		return false
+	}
+	host := strings.ToLower(u.Hostname())
+	for _, allowed := range s.iframeHosts {
+		if host == allowed || strings.HasSuffix(host, "."+allowed) {
+			return true
+		}
+	}
+	return false
+}
+
+// RenderPolicy is the bluemonday policy applied to rendered HTML. It mirrors
+// the write-time allowlist plus the markup the renderer itself produces.
+func (s *SanitizerService) RenderPolicy() *bluemonday.Policy {
+	policy := bluemonday.UGCPolicy()
+	policy.RequireNoFollowOnLinks(false)
+	policy.AllowAttrs("id").Matching(regexp.MustCompile(`^[\w:-]+$`)).OnElements("h1", "h2", "h3", "h4", "h5", "h6", "li", "sup", "div")
+	policy.AllowAttrs("class").Matching(regexp.MustCompile(`^[\w\s-]+$`)).OnElements("a", "code", "pre", "span", "div", "section", "sup", "ol", "li", "hr", "table", "tr", "td")
+	policy.AllowAttrs("role").Matching(regexp.MustCompile(`^doc-(noteref|backlink|endnotes)$`)).OnElements("a", "section", "div")
+	policy.AllowAttrs("align").Matching(regexp.MustCompile(`^(left|right|center)$`)).OnElements("th", "td")
+	policy.AllowStyles("text-align").Matching(regexp.MustCompile(`^(left|right|center)$`)).OnElements("th", "td")
+	policy.AllowAttrs("type", "checked", "disabled").OnElements("input")
+	policy.AllowAttrs("tabindex").Matching(regexp.MustCompile(`^0$`)).OnElements("pre")
+	policy.AllowElements("section", "input")
+
+	if _, ok := s.allowedTags["iframe"]; ok && len(s.iframeHosts) > 0 {
+		hosts := make([]string, len(s.iframeHosts))
+		for i, host := range s.iframeHosts {
+			hosts[i] = regexp.QuoteMeta(host)
+		}
+		policy.AllowAttrs("src").
+			Matching(regexp.MustCompile(`^https://([\w-]+\.)*(` + strings.Join(hosts, "|") + `)(/|$)`)).
+			OnElements("iframe")
+		policy.AllowAttrs("width", "height", "title", "allow", "allowfullscreen", "frameborder", "loading").OnElements("iframe")
+	}
+	return policy
+}
+
+func attrValue(token html.Token, key string) string {
+	for _, attr := range token.Attr {
+		if strings.EqualFold(attr.Key, key) {
+			return attr.Val
+		}
+	}
+	return ""
+}
+
+func safeURL(value string) bool {
+	scheme := urlScheme(value)
+	return scheme == "" || scheme == "http" || scheme == "https" || scheme == "mailto"
+}
+
+// urlScheme extracts the scheme the way browsers do, ignoring the whitespace
+// and control characters that are commonly used to disguise javascript: URLs.
+func urlScheme(value string) string {
+	cleaned := strings.Map(func(r rune) rune {
+		if r <= ' ' || r == 0x7f {
+			return -1
+		}
+		return r
+	}, value)
+	colon := strings.Index(cleaned, ":")
+	if colon <= 0 {
+		return ""
+	}
+	scheme := strings.ToLower(cleaned[:colon])
+	if strings.ContainsAny(scheme, "/?#") {
+		return ""
+	}
+	return scheme
+}
+
+func containsString(list []string, value string) bool {
+	for _, item := range list {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
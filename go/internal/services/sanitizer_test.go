@@ -0,0 +1,57 @@
+package services
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSanitizeTextRemovesJoinedTags(t *testing.T) {
+	s := NewSanitizerService()
+	for _, input := range []string{
+		"<<b>img src=x onerror=alert(1)>",
+		"<script>alert(1)</script>",
+		"Title</script>",
+	} {
+		cleaned, findings := s.SanitizeText("title", input)
+		if strings.ContainsAny(cleaned, "<>") {
+			t.Errorf("SanitizeText(%q) = %q, still contains markup", input, cleaned)
+		}
+		if len(findings) == 0 {
+			t.Errorf("SanitizeText(%q) reported no findings", input)
+		}
+	}
+}
+
+func TestSanitizeMarkdownRemovesDangerousMarkup(t *testing.T) {
+	s := NewSanitizerService()
+	cases := []struct {
+		input  string
+		banned string
+	}{
+		{"x <<!-- -->img src=x onerror=alert(1)> y", "onerror"},
+		{"<div><<x>img src=x onerror=alert(1)></div>", "<img"},
+		{"text <script>alert(1)</script> more", "</script>"},
+		{"see <javascript:alert(1)>", "javascript"},
+		{"[x](javascript&#58;alert(1))", "javascript"},
+		{"[x](&#106;avascript:alert(1))", "avascript"},
+		{"[x]: javascript&colon;alert(1)\n\n[y][x]", "avascript"},
+	}
+	for _, tc := range cases {
+		cleaned, findings := s.SanitizeMarkdown("content", tc.input)
+		if strings.Contains(cleaned, tc.banned) {
+			t.Errorf("SanitizeMarkdown(%q) = %q, still contains %q", tc.input, cleaned, tc.banned)
+		}
+		if len(findings) == 0 {
+			t.Errorf("SanitizeMarkdown(%q) reported no findings", tc.input)
+		}
+	}
+}
+
+func TestSanitizeMarkdownKeepsCode(t *testing.T) {
+	s := NewSanitizerService()
+	input := "Use `<script>` or `[x](javascript:void(0))`.\n\n```html\n<<b>img onerror=x>\n```\n"
+	cleaned, findings := s.SanitizeMarkdown("content", input)
+	if cleaned != input || len(findings) != 0 {
+		t.Errorf("SanitizeMarkdown changed code: %q, %v", cleaned, findings)
+	}
+}
@@ -0,0 +1,248 @@
+package services
+
+import (
+	"blog-api/internal/models"
+	"blog-api/internal/repository"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+// SpamSubmission is anything a reader can post anonymously.
+type SpamSubmission struct {
+	AuthorName  string
+	AuthorEmail string
+	Body        string
+	Honeypot    string
+	RenderedAt  *time.Time
+	SubmittedAt time.Time
+}
+
+type SpamSignal struct {
+	Check  string  `json:"check"`
+	Score  float64 `json:"score"`
+	Reason string  `json:"reason"`
+}
+
+type SpamVerdict struct {
+	Score   float64      `json:"score"`
+	IsSpam  bool         `json:"is_spam"`
+	Signals []SpamSignal `json:"signals"`
+}
+
+// SpamChecker is implemented by each heuristic. Additional checkers (e.g. an
+// external service) can be registered with SpamService.Register.
+type SpamChecker interface {
+	Name() string
+	Check(sub *SpamSubmission, settings *models.SpamSettings) (float64, string, error)
+}
+
+type SpamService struct {
+	repo     *repository.SpamRepository
+	checkers []SpamChecker
+
+	mu       sync.RWMutex
+	settings *models.SpamSettings
+	loadedAt time.Time
+}
+
+const spamSettingsTTL = 30 * time.Second
+
+func NewSpamService() *SpamService {
+	repo := repository.NewSpamRepository()
+	return &SpamService{
+		repo: repo,
+		checkers: []SpamChecker{
+			honeypotChecker{},
+			timingChecker{},
+			linkCountChecker{},
+			blocklistChecker{},
+			&duplicateChecker{repo: repo},
+		},
+	}
+}
+
+func (s *SpamService) Register(checker SpamChecker) {
+	s.checkers = append(s.checkers, checker)
+}
+
+func (s *SpamService) Settings() (*models.SpamSettings, error) {
+	s.mu.RLock()
+	if s.settings != nil && time.Since(s.loadedAt) < spamSettingsTTL {
+		settings := s.settings
+		s.mu.RUnlock()
+		return settings, nil
+	}
+	s.mu.RUnlock()
+
+	settings, err := s.repo.GetSettings()
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	s.settings = settings
+	s.loadedAt = time.Now()
+	s.mu.Unlock()
+	return settings, nil
+}
+
+func (s *SpamService) SaveSettings(settings *models.SpamSettings) error {
+	if err := s.repo.SaveSettings(settings); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	s.settings = settings
+	s.loadedAt = time.Now()
+	s.mu.Unlock()
+	return nil
+}
+
+func (s *SpamService) Evaluate(sub *SpamSubmission) (*SpamVerdict, error) {
+	settings, err := s.Settings()
+	if err != nil {
+		return nil, err
+	}
+
+	verdict := &SpamVerdict{Signals: []SpamSignal{}}
+	if !settings.Enabled {
+		return verdict, nil
+	}
+
+	for _, checker := range s.checkers {
+		score, reason, err := checker.Check(sub, settings)
+		if err != nil {
+			return nil, fmt.Errorf("spam check %s failed: %w", checker.Name(), err)
+		}
+		if score == 0 {
+			continue
+		}
+		verdict.Score += score
+		verdict.Signals = append(verdict.Signals, SpamSignal{Check: checker.Name(), Score: score, Reason: reason})
+	}
+
+	verdict.IsSpam = verdict.Score >= settings.Threshold
+	return verdict, nil
+}
+
+func ContentHash(body string) string {
+	normalized := strings.Join(strings.Fields(strings.ToLower(body)), " ")
+	sum := sha256.Sum256([]byte(normalized))
+	return hex.EncodeToString(sum[:])
+}
+
+type honeypotChecker struct{}
+
+func (honeypotChecker) Name() string { return "honeypot" }
+
+func (honeypotChecker) Check(sub *SpamSubmission, _ *models.SpamSettings) (float64, string, error) {
+	if strings.TrimSpace(sub.Honeypot) != "" {
+		return 10, "hidden honeypot field was filled in", nil
+	}
+	return 0, "", nil
+}
+
+type timingChecker struct{}
+
+func (timingChecker) Name() string { return "timing" }
+
+func (timingChecker) Check(sub *SpamSubmission, settings *models.SpamSettings) (float64, string, error) {
+	if settings.MinSubmitSeconds <= 0 {
+		return 0, "", nil
+	}
+	if sub.RenderedAt == nil {
+		return 1, "form render time missing", nil
+	}
+
+	elapsed := sub.SubmittedAt.Sub(*sub.RenderedAt)
+	switch {
+	case elapsed < 0:
+		return 2, "form render time is in the future", nil
+	case elapsed < time.Duration(settings.MinSubmitSeconds)*time.Second:
+		return 4, fmt.Sprintf("submitted %.1fs after the form was shown", elapsed.Seconds()), nil
+	}
+	return 0, "", nil
+}
+
+var spamLinkPattern = regexp.MustCompile(`(?i)\bhttps?://[^\s)\]]+|\bwww\.[^\s)\]]+`)
+
+type linkCountChecker struct{}
+
+func (linkCountChecker) Name() string { return "link_count" }
+
+func (linkCountChecker) Check(sub *SpamSubmission, settings *models.SpamSettings) (float64, string, error) {
+	links := len(spamLinkPattern.FindAllString(sub.Body, -1))
+	if links <= settings.MaxLinks {
+		return 0, "", nil
+	}
+	return float64(links-settings.MaxLinks) * 1.5, fmt.Sprintf("contains %d links (max %d)", links, settings.MaxLinks), nil
+}
+
+type blocklistChecker struct{}
+
+func (blocklistChecker) Name() string { return "blocklist" }
+
+func (blocklistChecker) Check(sub *SpamSubmission, settings *models.SpamSettings) (float64, string, error) {
+	text := strings.ToLower(sub.AuthorName + " " + sub.Body)
+
+	for _, word := range settings.BlockedWords {
+		word = strings.ToLower(strings.TrimSpace(word))
+		if word != "" && strings.Contains(text, word) {
+			return 5, fmt.Sprintf("contains blocked word %q", word), nil
+		}
+	}
+
+	hosts := []string{}
+	if at := strings.LastIndex(sub.AuthorEmail, "@"); at >= 0 {
+		hosts = append(hosts, strings.ToLower(sub.AuthorEmail[at+1:]))
+	}
+	for _, link := range spamLinkPattern.FindAllString(sub.Body, -1) {
+		if !strings.Contains(link, "://") {
+			link = "http://" + link
+		}
+		if u, err := url.Parse(link); err == nil {
+			hosts = append(hosts, strings.ToLower(u.Hostname()))
+		}
+	}
+
+	for _, domain := range settings.BlockedDomains {
+		domain = strings.ToLower(strings.TrimSpace(domain))
+		if domain == "" {
+			continue
+		}
+		for _, host := range hosts {
+			if host == domain || strings.HasSuffix(host, "."+domain) {
+				return 5, fmt.Sprintf("references blocked domain %q", domain), nil
+			}
+		}
+	}
+	return 0, "", nil
+}
+
+type duplicateChecker struct {
+	repo *repository.SpamRepository
+}
+
+func (*duplicateChecker) Name() string { return "duplicate" }
+
+func (d *duplicateChecker) Check(sub *SpamSubmission, settings *models.SpamSettings) (float64, string, error) {
+	if settings.DuplicateWindowHours <= 0 {
+		return 0, "", nil
+	}
+
+	since := sub.SubmittedAt.Add(-time.Duration(settings.DuplicateWindowHours) * time.Hour)
+	count, err := d.repo.CountRecentDuplicates(ContentHash(sub.Body), since)
+	if err != nil {
+		return 0, "", err
+	}
+	if count == 0 {
+		return 0, "", nil
+	}
+	return 3 + float64(count-1), fmt.Sprintf("identical content submitted %d time(s) recently", count), nil
+}
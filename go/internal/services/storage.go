@@ -0,0 +1,118 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path"
+	"strings"
+	"time"
+)
+
+var ErrObjectNotFound = errors.New("object not found")
+
+type StoredObject struct {
+	Key         string    `json:"key"`
+	URL         string    `json:"url"`
+	Size        int64     `json:"size"`
+	ContentType string    `json:"content_type,omitempty"`
+	ModTime     time.Time `json:"mod_time,omitempty"`
+}
+
+// Storage is where uploaded media lives. Keys are slash-separated relative
+// paths such as "blogs/<uuid>.jpg"; each backend maps them to its own
+// naming scheme.
+type Storage interface {
+	Name() string
+	Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) (*StoredObject, error)
+	Delete(ctx context.Context, key string) error
+	// Open streams an object's content back. Missing keys give
+	// ErrObjectNotFound.
+	Open(ctx context.Context, key string) (io.ReadCloser, error)
+	URL(key string) string
+	Stat(ctx context.Context, key string) (*StoredObject, error)
+	// List returns every object whose key starts with prefix.
+	List(ctx context.Context, prefix string) ([]*StoredObject, error)
+	// Key maps a public URL back to the key it was stored under. It reports
+	// false for URLs this backend did not produce.
+	Key(url string) (string, bool)
+}
+
+// NewStorage builds the backend named by STORAGE_DRIVER (cloudinary, local
+// or s3). Without it, Cloudinary is used when CLOUDINARY_URL is set and the
+// local filesystem otherwise. A misconfigured backend is an error rather
+// than a silently missing feature.
+func NewStorage() (Storage, error) {
+	driver := strings.ToLower(strings.TrimSpace(os.Getenv("STORAGE_DRIVER")))
+	if driver == "" {
+		driver = "local"
+		if os.Getenv("CLOUDINARY_URL") != "" {
+			driver = "cloudinary"
+		}
+	}
+
+	var storage Storage
+	var err error
+	switch driver {
+	case "cloudinary":
+		storage, err = NewCloudinaryService()
+	case "local":
+		storage, err = NewLocalStorage()
+	case "s3":
+		storage, err = NewS3Storage()
+	default:
+		return nil, fmt.Errorf("unknown STORAGE_DRIVER %q (expected cloudinary, local or s3)", driver)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize %s storage: %w", driver, err)
+	}
+	return storage, nil
+}
+
+// keyAfter strips base plus a slash from url and validates what is left.
+func keyAfter(url, base string) (string, bool) {
+	rest, ok := strings.CutPrefix(url, base+"/")
+	if !ok {
+		return "", false
+	}
+	if i := strings.IndexAny(rest, "?#"); i >= 0 {
+		rest = rest[:i]
+	}
+	key, err := cleanKey(rest)
+	return key, err == nil
+}
+
+// cleanKey rejects keys that could escape the storage root.
+func cleanKey(key string) (string, error) {
+	cleaned := path.Clean("/" + key)[1:]
+	if cleaned == "" || cleaned != key || strings.HasPrefix(key, "/") {
+		return "", fmt.Errorf("invalid storage key %q", key)
+	}
+	return cleaned, nil
+}
+
+// fetchURL downloads a public URL. Non-2xx responses are errors, and a 404
+// is reported as ErrObjectNotFound.
+func fetchURL(ctx context.Context, url string) (io.ReadCloser, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := fetchClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch %s: %w", url, err)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		resp.Body.Close()
+		if resp.StatusCode == http.StatusNotFound {
+			return nil, ErrObjectNotFound
+		}
+		return nil, fmt.Errorf("failed to fetch %s: %s", url, resp.Status)
+	}
+	return resp.Body, nil
+}
+
+var fetchClient = &http.Client{Timeout: 30 * time.Second}
@@ -0,0 +1,96 @@
+package utils
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+var analyticsSecret []byte
+
+// InitAnalyticsSecret loads ANALYTICS_SALT, which keys every visitor hash.
+// It is required rather than generated: reactions and comments recognise
+// visitors by their hash, so it has to survive restarts and be shared by
+// all replicas.
+func InitAnalyticsSecret() error {
+	secret := os.Getenv("ANALYTICS_SALT")
+	if secret == "" {
+		return fmt.Errorf("ANALYTICS_SALT not set")
+	}
+	analyticsSecret = []byte(secret)
+	return nil
+}
+
+// VisitorHash derives an anonymous visitor ID from IP and User-Agent. The
+// day is hashed along with them so the same visitor hashes differently
+// tomorrow.
+func VisitorHash(ip, userAgent string, day time.Time) string {
+	return keyedHash(day.UTC().Format("2006-01-02"), ip, userAgent)
+}
+
+// ScopedVisitorHash is a stable (non-rotating) variant used where a visitor
+// must be recognised across days, e.g. to de-duplicate reactions.
+func ScopedVisitorHash(scope, ip, userAgent string) string {
+	return keyedHash(scope, ip, userAgent)
+}
+
+func keyedHash(parts ...string) string {
+	mac := hmac.New(sha256.New, analyticsSecret)
+	for i, part := range parts {
+		if i > 0 {
+			mac.Write([]byte{0})
+		}
+		mac.Write([]byte(part))
+	}
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func ReferrerHost(referrer, ownHost string) string {
+	if referrer == "" {
+		return ""
+	}
+	u, err := url.Parse(referrer)
+	if err != nil || u.Host == "" {
+		return ""
+	}
+	host := strings.TrimPrefix(strings.ToLower(u.Hostname()), "www.")
+	if ownHost != "" && host == strings.TrimPrefix(strings.ToLower(ownHost), "www.") {
+		return ""
+	}
+	if len(host) > 255 {
+		host = host[:255]
+	}
+	return host
+}
+
+func DeviceClass(userAgent string) string {
+	ua := strings.ToLower(userAgent)
+	switch {
+	case ua == "":
+		return "unknown"
+	case containsAny(ua, "bot", "crawler", "spider", "slurp", "curl", "wget", "python-requests", "headless", "preview"):
+		return "bot"
+	case containsAny(ua, "ipad", "tablet", "kindle", "silk", "playbook"):
+		return "tablet"
+	case strings.Contains(ua, "android") && !strings.Contains(ua, "mobile"):
+		return "tablet"
+	case containsAny(ua, "mobi", "iphone", "ipod", "android", "windows phone"):
+		return "mobile"
+	default:
+		return "desktop"
+	}
+}
+
+func containsAny(s string, substrs ...string) bool {
+	for _, sub := range substrs {
+		if strings.Contains(s, sub) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,94 @@
+package utils
+
+import (
+	"html"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var (
+	mdLiteCode   = regexp.MustCompile("`([^`\n]+)`")
+	mdLiteBold   = regexp.MustCompile(`\*\*([^*\n]+)\*\*`)
+	mdLiteItalic = regexp.MustCompile(`(^|[^*\w])[*_]([^*_\n]+)[*_]($|[^*\w])`)
+	mdLiteLink   = regexp.MustCompile(`\[([^\]\n]+)\]\((https?://[^\s)\x00]+|mailto:[^\s)\x00]+)\)`)
+	mdLiteURL    = regexp.MustCompile(`(^|[\s(])(https?://[^\s<\x00]+[^\s<.,;:!?)\]'"\x00])`)
+	mdLiteBlanks = regexp.MustCompile(`\n{2,}`)
+)
+
+// RenderMarkdownLite converts the small Markdown subset allowed in reader
+// comments (bold, italic, inline code, links, quotes, paragraphs) to HTML.
+// The input is HTML-escaped before any markup is added, so the output can
+// only contain the tags produced here.
+func RenderMarkdownLite(input string) string {
+	// NUL bytes are reserved for the placeholders in renderInlineLite.
+	text := strings.ReplaceAll(strings.TrimSpace(strings.ReplaceAll(input, "\x00", "")), "\r\n", "\n")
+	if text == "" {
+		return ""
+	}
+
+	paragraphs := mdLiteBlanks.Split(text, -1)
+	rendered := make([]string, 0, len(paragraphs))
+	for _, para := range paragraphs {
+		lines := strings.Split(para, "\n")
+		quote := true
+		for _, line := range lines {
+			if !strings.HasPrefix(line, ">") {
+				quote = false
+				break
+			}
+		}
+		if quote {
+			for i, line := range lines {
+				lines[i] = strings.TrimSpace(strings.TrimPrefix(line, ">"))
+			}
+		}
+
+		for i, line := range lines {
+			lines[i] = renderInlineLite(line)
+		}
+		body := strings.Join(lines, "<br>\n")
+
+		if quote {
+			rendered = append(rendered, "<blockquote><p>"+body+"</p></blockquote>")
+		} else {
+			rendered = append(rendered, "<p>"+body+"</p>")
+		}
+	}
+	return strings.Join(rendered, "\n")
+}
+
+func renderInlineLite(line string) string {
+	escaped := html.EscapeString(line)
+
+	// Code spans and links are pulled out first, so code is not formatted
+	// and emphasis never reaches into an href.
+	var tokens []string
+	hold := func(token string) string {
+		tokens = append(tokens, token)
+		return "\x00" + strconv.Itoa(len(tokens)-1) + "\x00"
+	}
+	escaped = mdLiteCode.ReplaceAllStringFunc(escaped, func(m string) string {
+		return hold("<code>" + mdLiteCode.FindStringSubmatch(m)[1] + "</code>")
+	})
+	escaped = mdLiteLink.ReplaceAllStringFunc(escaped, func(m string) string {
+		parts := mdLiteLink.FindStringSubmatch(m)
+		return hold(`<a href="` + parts[2] + `" rel="nofollow ugc noopener" target="_blank">` + emphasizeLite(parts[1]) + `</a>`)
+	})
+	escaped = mdLiteURL.ReplaceAllStringFunc(escaped, func(m string) string {
+		parts := mdLiteURL.FindStringSubmatch(m)
+		return parts[1] + hold(`<a href="`+parts[2]+`" rel="nofollow ugc noopener" target="_blank">`+parts[2]+`</a>`)
+	})
+	escaped = emphasizeLite(escaped)
+
+	// Link text may hold a code span, so later tokens are restored first.
+	for i := len(tokens) - 1; i >= 0; i-- {
+		escaped = strings.Replace(escaped, "\x00"+strconv.Itoa(i)+"\x00", tokens[i], 1)
+	}
+	return escaped
+}
+
+func emphasizeLite(text string) string {
+	text = mdLiteBold.ReplaceAllString(text, "<strong>$1</strong>")
+	return mdLiteItalic.ReplaceAllString(text, "$1<em>$2</em>$3")
+}
@@ -0,0 +1,47 @@
+package utils
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+)
+
+func SiteURL() string {
+	return strings.TrimRight(os.Getenv("SITE_URL"), "/")
+}
+
+// CheckSiteURL requires SITE_URL to be an absolute URL: feeds and sitemaps
+// are read away from the site, where relative links lead nowhere.
+func CheckSiteURL() error {
+	u, err := url.Parse(SiteURL())
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("SITE_URL must be set to an absolute http(s) URL")
+	}
+	return nil
+}
+
+// APIURL is the public address of the API itself (API_PUBLIC_URL), used for
+// links to files it generates. It is empty when not configured.
+func APIURL() string {
+	return strings.TrimRight(os.Getenv("API_PUBLIC_URL"), "/")
+}
+
+func SiteTitle() string {
+	if title := os.Getenv("SITE_TITLE"); title != "" {
+		return title
+	}
+	return "Blog"
+}
+
+func SiteDescription() string {
+	return os.Getenv("SITE_DESCRIPTION")
+}
+
+func SiteAuthor() string {
+	return os.Getenv("SITE_AUTHOR")
+}
+
+func PostURL(slug string) string {
+	return SiteURL() + "/blogs/" + url.PathEscape(slug)
+}
@@ -1,27 +1,87 @@
 package utils
 
 import (
-	"crypto/rand"
-	"encoding/hex"
-	"regexp"
+	"os"
+	"strconv"
 	"strings"
+	"sync"
 )
 
+const defaultSlugMaxLength = 80
+
+// reservedSlugs would shadow routes or read as system pages on the site.
+var reservedSlugs = map[string]bool{
+	"admin": true, "api": true, "archive": true, "atom": true, "blog": true,
+	"blogs": true, "category": true, "dashboard": true, "draft": true, "drafts": true,
+	"edit": true, "feed": true, "index": true, "login": true, "logout": true,
+	"meta": true, "new": true, "page": true, "preview": true, "public": true,
+	"robots": true, "rss": true, "search": true, "settings": true, "sitemap": true,
+	"sitemaps": true, "slug": true, "static": true, "tag": true, "tags": true,
+}
+
+var (
+	slugConfigOnce sync.Once
+	slugMaxLength  int
+	extraReserved  map[string]bool
+)
+
+func loadSlugConfig() {
+	slugConfigOnce.Do(func() {
+		slugMaxLength = defaultSlugMaxLength
+		if n, err := strconv.Atoi(os.Getenv("SLUG_MAX_LENGTH")); err == nil && n >= 8 && n <= 200 {
+			slugMaxLength = n
+		}
+		extraReserved = make(map[string]bool)
+		for _, word := range strings.Split(os.Getenv("SLUG_RESERVED_WORDS"), ",") {
+			if word = strings.TrimSpace(strings.ToLower(word)); word != "" {
+				extraReserved[word] = true
+			}
+		}
+	})
+}
+
+// GenerateSlug turns a title into a lowercase ASCII slug. Non-Latin scripts
+// are transliterated, accents dropped, and long titles cut at a word
+// boundary. Uniqueness is the repository's job; this never adds a suffix.
 func GenerateSlug(title string) string {
-	slug := strings.ToLower(title)
-	
-	reg := regexp.MustCompile(`[^\w\s-]`)
-	slug = reg.ReplaceAllString(slug, "")
-	
-	reg = regexp.MustCompile(`[-\s]+`)
-	slug = reg.ReplaceAllString(slug, "-")
-	
-	slug = strings.Trim(slug, "- ")
-	
-	b := make([]byte, 6)
-	rand.Read(b)
-	suffix := hex.EncodeToString(b)
-	
-	return slug + "-" + suffix
+	loadSlugConfig()
+
+	var b strings.Builder
+	dash := false
+	for _, r := range Transliterate(title) {
+		switch {
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9':
+			b.WriteRune(r)
+			dash = false
+		case r >= 'A' && r <= 'Z':
+			b.WriteRune(r + ('a' - 'A'))
+			dash = false
+		case r == '\'' || r == '’':
+			// "don't" reads better as "dont" than "don-t".
+		default:
+			if !dash && b.Len() > 0 {
+				b.WriteByte('-')
+				dash = true
+			}
+		}
+	}
+
+	slug := strings.Trim(b.String(), "-")
+	if len(slug) > slugMaxLength {
+		wordEnd := slug[slugMaxLength] == '-'
+		slug = slug[:slugMaxLength]
+		if cut := strings.LastIndexByte(slug, '-'); !wordEnd && cut >= slugMaxLength/2 {
+			slug = slug[:cut]
+		}
+		slug = strings.Trim(slug, "-")
+	}
+	if slug == "" {
+		slug = "post"
+	}
+	return slug
 }
 
+func IsReservedSlug(slug string) bool {
+	loadSlugConfig()
+	return reservedSlugs[slug] || extraReserved[slug]
+}
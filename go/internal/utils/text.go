@@ -0,0 +1,22 @@
+package utils
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// Truncate shortens s to at most max runes, cutting at the last word boundary
+// and appending an ellipsis when anything was removed.
+func Truncate(s string, max int) string {
+	s = strings.Join(strings.Fields(s), " ")
+	if utf8.RuneCountInString(s) <= max {
+		return s
+	}
+
+	runes := []rune(s)
+	cut := string(runes[:max])
+	if i := strings.LastIndex(cut, " "); i > len(cut)/2 {
+		cut = cut[:i]
+	}
+	return strings.TrimRight(cut, " ,.;:-") + "…"
+}
@@ -0,0 +1,123 @@
+package utils
+
+import (
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+var latinSpecial = map[rune]string{
+	'ß': "ss", 'æ': "ae", 'Æ': "AE", 'œ': "oe", 'Œ': "OE", 'ø': "o", 'Ø': "O",
+	'đ': "d", 'Đ': "D", 'ð': "d", 'Ð': "D", 'ł': "l", 'Ł': "L", 'þ': "th", 'Þ': "Th",
+	'ı': "i", 'ŋ': "ng", 'Ŋ': "Ng", '&': " and ", '@': " at ", '+': " plus ",
+}
+
+var cyrillic = map[rune]string{
+	'а': "a", 'б': "b", 'в': "v", 'г': "g", 'д': "d", 'е': "e", 'ё': "yo", 'ж': "zh",
+	'з': "z", 'и': "i", 'й': "y", 'к': "k", 'л': "l", 'м': "m", 'н': "n", 'о': "o",
+	'п': "p", 'р': "r", 'с': "s", 'т': "t", 'у': "u", 'ф': "f", 'х': "kh", 'ц': "ts",
+	'ч': "ch", 'ш': "sh", 'щ': "shch", 'ъ': "", 'ы': "y", 'ь': "", 'э': "e", 'ю': "yu",
+	'я': "ya", 'є': "ye", 'і': "i", 'ї': "yi", 'ґ': "g", 'ў': "u", 'ђ': "dj", 'ј': "j",
+	'љ': "lj", 'њ': "nj", 'ћ': "c", 'џ': "dz",
+}
+
+var greek = map[rune]string{
+	'α': "a", 'β': "v", 'γ': "g", 'δ': "d", 'ε': "e", 'ζ': "z", 'η': "i", 'θ': "th",
+	'ι': "i", 'κ': "k", 'λ': "l", 'μ': "m", 'ν': "n", 'ξ': "x", 'ο': "o", 'π': "p",
+	'ρ': "r", 'σ': "s", 'ς': "s", 'τ': "t", 'υ': "y", 'φ': "f", 'χ': "ch", 'ψ': "ps",
+	'ω': "o",
+}
+
+// Devanagari consonants carry an inherent "a" that a following vowel sign
+// replaces and a virama removes.
+var devanagariConsonants = map[rune]string{
+	'क': "k", 'ख': "kh", 'ग': "g", 'घ': "gh", 'ङ': "n", 'च': "ch", 'छ': "chh", 'ज': "j",
+	'झ': "jh", 'ञ': "n", 'ट': "t", 'ठ': "th", 'ड': "d", 'ढ': "dh", 'ण': "n", 'त': "t",
+	'थ': "th", 'द': "d", 'ध': "dh", 'न': "n", 'प': "p", 'फ': "ph", 'ब': "b", 'भ': "bh",
+	'म': "m", 'य': "y", 'र': "r", 'ल': "l", 'ळ': "l", 'व': "v", 'श': "sh", 'ष': "sh",
+	'स': "s", 'ह': "h",
+}
+
+// A nukta after a consonant marks sounds borrowed from Persian and English.
+var devanagariNukta = map[rune]string{
+	'क': "q", 'ख': "kh", 'ग': "g", 'ज': "z", 'ड': "r", 'ढ': "rh", 'फ': "f",
+}
+
+var devanagariVowels = map[rune]string{
+	'अ': "a", 'आ': "a", 'इ': "i", 'ई': "i", 'उ': "u", 'ऊ': "u", 'ऋ': "ri", 'ए': "e",
+	'ऐ': "ai", 'ओ': "o", 'औ': "au", 'ऑ': "o",
+	'ं': "n", 'ँ': "n", 'ः': "h", 'ॐ': "om",
+	'०': "0", '१': "1", '२': "2", '३': "3", '४': "4", '५': "5", '६': "6", '७': "7", '८': "8", '९': "9",
+}
+
+var devanagariSigns = map[rune]string{
+	'ा': "a", 'ि': "i", 'ी': "i", 'ु': "u", 'ू': "u", 'ृ': "ri", 'े': "e", 'ै': "ai",
+	'ो': "o", 'ौ': "au", 'ॉ': "o", '्': "",
+}
+
+// Transliterate approximates text in ASCII: accents are dropped and
+// Cyrillic, Greek and Devanagari are romanised. Scripts it does not know are
+// left as they are for the caller to discard.
+func Transliterate(s string) string {
+	runes := []rune(norm.NFC.String(s))
+	var b strings.Builder
+
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+		if t, ok := latinSpecial[r]; ok {
+			b.WriteString(t)
+			continue
+		}
+		if r < unicode.MaxASCII {
+			b.WriteRune(r)
+			continue
+		}
+
+		if cons, ok := devanagariConsonants[r]; ok {
+			if i+1 < len(runes) && runes[i+1] == '\u093C' {
+				if borrowed, ok := devanagariNukta[r]; ok {
+					cons = borrowed
+				}
+				i++
+			}
+			b.WriteString(cons)
+
+			next := rune(0)
+			if i+1 < len(runes) {
+				next = runes[i+1]
+			}
+			// Word-final consonants drop the inherent vowel, as spoken Hindi
+			// does ("भारत" is "bharat", not "bharata").
+			if sign, ok := devanagariSigns[next]; ok {
+				b.WriteString(sign)
+				i++
+			} else if _, ok := devanagariConsonants[next]; ok || (next >= '\u0901' && next <= '\u0903') {
+				b.WriteByte('a')
+			}
+			continue
+		}
+		if v, ok := devanagariVowels[r]; ok {
+			b.WriteString(v)
+			continue
+		}
+
+		lower := unicode.ToLower(r)
+		base := []rune(norm.NFD.String(string(lower)))
+		if t, ok := cyrillic[base[0]]; ok {
+			b.WriteString(t)
+			continue
+		}
+		if t, ok := greek[base[0]]; ok {
+			b.WriteString(t)
+			continue
+		}
+
+		for _, br := range norm.NFD.String(string(r)) {
+			if !unicode.Is(unicode.Mn, br) {
+				b.WriteRune(br)
+			}
+		}
+	}
+	return b.String()
+}
@@ -26,9 +26,17 @@ func main() {
 		log.Fatalf("Failed to migrate database: %v", err)
 	}
 
+	if len(os.Args) > 1 {
+		if err := runCommand(os.Args[1], os.Args[2:]); err != nil {
+			log.Fatalf("%s: %v", os.Args[1], err)
+		}
+		return
+	}
+
 	router := gin.Default()
 	router.RemoveExtraSlash = true
-	router.MaxMultipartMemory = 10 << 20
+	// Uploaded files beyond this are spooled to temporary files, not memory.
+	router.MaxMultipartMemory = 1 << 20
 
 	router.Use(cors.New(cors.Config{
 		AllowOrigins:     []string{allowedOrigins},
@@ -39,7 +47,9 @@ func main() {
 		MaxAge:           12 * time.Hour,
 	}))
 
-	routes.SetupRoutes(router)
+	if err := routes.SetupRoutes(router); err != nil {
+		log.Fatalf("Failed to set up routes: %v", err)
+	}
 
 	port := getEnv("SERVER_PORT")
 	log.Printf("Server starting on port %s", port)